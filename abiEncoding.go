@@ -0,0 +1,205 @@
+package merkletree
+
+//
+// Solidity ABI encoding (abi.encode) of a tuple of values, as used by
+// OpenZeppelin's StandardMerkleTree to encode its leaves.
+//
+// Supported types:
+//
+//	- address, bool
+//	- uint<M>, int<M> (M: 8..256, multiple of 8; uint, int: 256)
+//	- bytes<M> (M: 1..32)
+//	- bytes, string (dynamic)
+//
+// Values:
+//
+//	- address, bytes<M>, bytes: hex string ("0x..."), or []byte
+//	- uint<M>, int<M>: decimal or hex ("0x...") string, json.Number, *big.Int, Go integers
+//	- bool: bool
+//	- string: string
+//
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const abiWordSize = 32
+
+// ABI encoding of values, of the given types.
+func abiEncode(types []string, values []any) ([]byte, error) {
+	if len(types) != len(values) {
+		return nil, &ABIEncodingErr{fmt.Sprintf("%d values for %d types", len(values), len(types))}
+	}
+
+	head := make([]byte, 0, abiWordSize*len(types))
+	tail := []byte{}
+	for i, abiType := range types {
+		switch abiType {
+		case "string", "bytes":
+			data, err := abiDynamicValue(abiType, values[i])
+			if err != nil {
+				return nil, err
+			}
+			head = append(head, abiUint(big.NewInt(int64(abiWordSize*len(types)+len(tail))))...)
+			tail = append(tail, abiUint(big.NewInt(int64(len(data))))...)
+			tail = append(tail, abiPadRight(data)...)
+
+		default:
+			word, err := abiStaticValue(abiType, values[i])
+			if err != nil {
+				return nil, err
+			}
+			head = append(head, word...)
+		}
+	}
+
+	return append(head, tail...), nil
+}
+
+// One word (32 bytes) encoding of a static type.
+func abiStaticValue(abiType string, value any) ([]byte, error) {
+	switch {
+	case abiType == "address":
+		address, err := abiBytes(value)
+		if err != nil || len(address) != 20 {
+			return nil, &ABIEncodingErr{fmt.Sprintf("invalid address %v", value)}
+		}
+		return abiPadLeft(address), nil
+
+	case abiType == "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, &ABIEncodingErr{fmt.Sprintf("invalid bool %v", value)}
+		}
+		return abiUint(big.NewInt(int64(If(b, 1, 0)))), nil
+
+	case strings.HasPrefix(abiType, "uint"), strings.HasPrefix(abiType, "int"):
+		signed := strings.HasPrefix(abiType, "int")
+		bits, err := abiTypeSize(strings.TrimPrefix(strings.TrimPrefix(abiType, "u"), "int"), 256, 8, 256)
+		if err != nil || bits%8 != 0 {
+			return nil, &ABIEncodingErr{fmt.Sprintf("unsupported type %s", abiType)}
+		}
+		n, err := abiInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		return abiInt(n, bits, signed)
+
+	case strings.HasPrefix(abiType, "bytes"):
+		size, err := abiTypeSize(strings.TrimPrefix(abiType, "bytes"), 0, 1, 32)
+		if err != nil {
+			return nil, &ABIEncodingErr{fmt.Sprintf("unsupported type %s", abiType)}
+		}
+		data, err := abiBytes(value)
+		if err != nil || len(data) != size {
+			return nil, &ABIEncodingErr{fmt.Sprintf("invalid %s %v", abiType, value)}
+		}
+		return abiPadRight(data), nil
+	}
+
+	return nil, &ABIEncodingErr{fmt.Sprintf("unsupported type %s", abiType)}
+}
+
+// Raw content of a dynamic type.
+func abiDynamicValue(abiType string, value any) ([]byte, error) {
+	if abiType == "string" {
+		s, ok := value.(string)
+		if !ok {
+			return nil, &ABIEncodingErr{fmt.Sprintf("invalid string %v", value)}
+		}
+		return []byte(s), nil
+	}
+
+	data, err := abiBytes(value)
+	if err != nil {
+		return nil, &ABIEncodingErr{fmt.Sprintf("invalid bytes %v", value)}
+	}
+	return data, nil
+}
+
+// Size suffix of a type (ie: 256 of uint256), within [min, max]; defaultSize if there is none.
+func abiTypeSize(suffix string, defaultSize, min, max int) (int, error) {
+	if suffix == "" && defaultSize > 0 {
+		return defaultSize, nil
+	}
+
+	size, err := strconv.Atoi(suffix)
+	if err != nil || size < min || size > max {
+		return 0, &ABIEncodingErr{fmt.Sprintf("invalid type size %q", suffix)}
+	}
+
+	return size, nil
+}
+
+// Two's complement word of n, range checked for a (u)int of bits.
+func abiInt(n *big.Int, bits int, signed bool) ([]byte, error) {
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(bits-If(signed, 1, 0)))
+	min := If(signed, new(big.Int).Neg(limit), big.NewInt(0))
+	if n.Cmp(min) < 0 || n.Cmp(limit) >= 0 {
+		return nil, &ABIEncodingErr{fmt.Sprintf("%s out of range for %d bits", n, bits)}
+	}
+
+	if n.Sign() < 0 {
+		n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+
+	return abiUint(n), nil
+}
+
+func abiUint(n *big.Int) []byte {
+	return n.FillBytes(make([]byte, abiWordSize))
+}
+
+func abiPadLeft(data []byte) []byte {
+	return append(make([]byte, abiWordSize-len(data)), data...)
+}
+
+func abiPadRight(data []byte) []byte {
+	padding := (abiWordSize - len(data)%abiWordSize) % abiWordSize
+	return append(append([]byte{}, data...), make([]byte, padding)...)
+}
+
+func abiInteger(value any) (*big.Int, error) {
+	switch v := value.(type) {
+	case *big.Int:
+		return v, nil
+	case int:
+		return big.NewInt(int64(v)), nil
+	case int64:
+		return big.NewInt(v), nil
+	case uint64:
+		return new(big.Int).SetUint64(v), nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= 1<<53 {
+			return big.NewInt(int64(v)), nil
+		}
+	case json.Number:
+		return abiInteger(string(v))
+	case string:
+		n, ok := new(big.Int).SetString(v, 0)
+		if ok {
+			return n, nil
+		}
+	}
+
+	return nil, &ABIEncodingErr{fmt.Sprintf("invalid integer %v", value)}
+}
+
+func abiBytes(value any) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		if strings.HasPrefix(v, "0x") || strings.HasPrefix(v, "0X") {
+			return hex.DecodeString(v[2:])
+		}
+	}
+
+	return nil, &ABIEncodingErr{fmt.Sprintf("invalid hex value %v", value)}
+}
@@ -14,31 +14,41 @@ func (ms *MerkleService) processBinaryTreeRequest(ctx context.Context) error {
 		return &InvalidContextProcessTypeErr{contextProcessType.(string)}
 	}
 
-	var index, startIndex int
-	// Get starting index: if 2^x > length then idx = 2^x - length (See documentation for more details)
-	startIndex = int(math.Pow(2, math.Ceil(math.Log2(float64(len(ms.Leaves)))))) - len(ms.Leaves)
+	return ms.binaryTree(ctx)
+}
 
-	for index = startIndex; index < len(ms.Leaves); index += 2 {
-		// - combine (concatenate) hash of left and right (in couple)
-		// - encode it with requested algorithm
-		// - Zero (nil) out the right element's value
-		ms.Leaves[index] = ms.hashGenerator(append(ms.Leaves[index][:], ms.Leaves[index+1][:]...))
-		ms.Leaves[index+1] = []byte{}
+// Pair the nodes from the starting index on, so that the next level is a power of two,
+// then pair level by level.
+func (ms *MerkleService) binaryTree(ctx context.Context) error {
+	if len(ms.Leaves) == 1 {
+		return ms.singleLeafRoot()
 	}
 
-	ms.removeNillBytes(BinaryTree, startIndex)
+	startIndex := binaryTreeStartIndex(len(ms.Leaves))
+
+	ms.proveLevel(startIndex)
+
+	// - combine (concatenate) hash of left and right (in couple)
+	// - encode it with requested algorithm
+	ms.hashLevel(startIndex)
+	ms.recordLevel()
+
+	return ms.pairLevels(ctx)
+}
 
+// Pair level by level, every level has an even number of nodes (power of two).
+func (ms *MerkleService) pairLevels(ctx context.Context) error {
 	for len(ms.Leaves) > 1 {
-		for index = 0; index < len(ms.Leaves); index += 2 {
-			// - combine (concatenate) hash of left and right (in couple)
-			// - encode it with requested algorithm
-			// - Zero (nil) out the right element's value
-			ms.Leaves[index] = ms.hashGenerator(append(ms.Leaves[index][:], ms.Leaves[index+1][:]...))
-			ms.Leaves[index+1] = []byte{}
+		if err := checkContext(ctx); err != nil {
+			return err
 		}
 
-		// Removenill bytes
-		ms.removeNillBytes(NopProcess, 0)
+		ms.proveLevel(0)
+
+		// - combine (concatenate) hash of left and right (in couple)
+		// - encode it with requested algorithm
+		ms.hashLevel(0)
+		ms.recordLevel()
 	}
 
 	ms.ProcessResult = ms.Leaves[0]
@@ -46,6 +56,11 @@ func (ms *MerkleService) processBinaryTreeRequest(ctx context.Context) error {
 	return nil
 }
 
+// Get starting index: if 2^x > length then idx = 2^x - length (See documentation for more details)
+func binaryTreeStartIndex(count int) int {
+	return int(math.Pow(2, math.Ceil(math.Log2(float64(count))))) - count
+}
+
 // Returns json string with all available hash functions.
 func AvailableAlgorithms() (string, error) {
 	type availableJson struct {
@@ -53,9 +68,14 @@ func AvailableAlgorithms() (string, error) {
 	}
 	jsonResult := &availableJson{}
 
+	registryLock.RLock()
 	for algorithmName := range AlgorithmRegistry {
 		jsonResult.Algorithms = append(jsonResult.Algorithms, algorithmName)
 	}
+	for algorithmName := range xofRegistry {
+		jsonResult.Algorithms = append(jsonResult.Algorithms, algorithmName)
+	}
+	registryLock.RUnlock()
 
 	sort.Strings(jsonResult.Algorithms)
 	jsonEncodedAlgorithms, err := json.Marshal(jsonResult)
@@ -0,0 +1,33 @@
+package merkletree
+
+//
+// Bitcoin block merkle root process type.
+//
+//	- Data: the block's txids in display order (as block explorers and RPC show them).
+//	- Txids are reversed to internal byte order, the root is reversed back to display order.
+//	- Nodes: double SHA-256 (SHA256D) of left || right.
+//	- An odd level duplicates its last node (DupeAppend), but a single
+//	  transaction is the root itself (it is not hashed with itself).
+//
+// Proof hashes are in internal byte order, as in merkleblock messages.
+//
+
+import (
+	"context"
+)
+
+func (ms *MerkleService) processBitcoinRequest(ctx context.Context) error {
+	const ThisProcess = 5
+	contextProcessType := ctx.Value(contextKeyRequestID)
+	if contextProcessType != processTypes[ThisProcess] {
+		return &InvalidContextProcessTypeErr{contextProcessType.(string)}
+	}
+
+	// Single transaction (ie: a coinbase only block): it is the root.
+	if len(ms.Leaves) == 1 {
+		ms.ProcessResult = ms.Leaves[0]
+		return nil
+	}
+
+	return ms.duplicateAndAppend(ctx)
+}
@@ -0,0 +1,332 @@
+package merkletree
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// Bitcoin mainnet blocks: txids and header merkle root, in display order.
+// (586, 2812, 277647: from btcd's blockchain/testdata block files)
+var btcBlocks = []struct {
+	height int
+	txids  []string
+	root   string
+}{
+	{0, []string{
+		"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+	}, "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"},
+	{170, []string{
+		"b1fea52486ce0c62bb442b530a3f0132b826c74e473d1f2c220bfa78111c5082",
+		"f4184fc596403b9d638783cf57adfe4c75c605f6356fbc91338530e9831e9e16",
+	}, "7dac2c5666815c17a3b36427de37bb9d2e2c5ccec3f8633eb91a4205cb4c10ff"},
+	{100000, []string{
+		"8c14f0db3df150123e6f3dbbf30f8b955a8249b62ac1d1ff16284aefa3d06d87",
+		"fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4",
+		"6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4",
+		"e9a66845e05d5abc0ad04ec80f774a7e585c6e8db975962d069a522137b80c1d",
+	}, "f3e94742aca4b5ef85488dc37c06c3282295ffec960994b2c0d5ac2a25a95766"},
+	// 3 txs: the last one is duplicated
+	{586, []string{
+		"d45724bacd1480b0c94d363ebf59f844fb54e60cdfda0cd38ef67154e9d0bc43",
+		"4d6edbeb62735d45ff1565385a8b0045f066055c9425e21540ea7a8060f08bf2",
+		"6bf363548b08aa8761e278be802a2d84b8e40daefe8150f9af7dd7b65a0de49f",
+	}, "197b3d968ce463aa5da7d8eeba8af35eba80ded4e4fe6808e6cc0dd1c069594d"},
+	// 6 txs: odd second level
+	{2812, []string{
+		"73c145f4a4ad7375a6fd0c2aa2de5ff2776033151f1a077bfa2ae893b0f4fa7b",
+		"00e45be5b605fdb2106afa4cef5992ee6d4e3724de5dc8b13e729a3fc3ad4b94",
+		"74c1a6dd6e88f73035143f8fc7420b5c395d28300a70bb35b943f7f2eddc656d",
+		"131f68261e28a80c3300b048c4c51f3ca4745653ba7ad6b20cc9188322818f25",
+		"a64be218809b61ac67ddc7f6c7f9fbebfe420cf75fe0318ebc727f060df48b37",
+		"8f5db6d157f79f2649719d5c3ff12eb5502edf098dbfb69d6ce58363e6ff293f",
+	}, "289a86c44c4698fd8f181929dc2dd3c25820c959eab28980b27bb3cf8fcacb65"},
+	// 213 txs: odd levels of 213, 107, 27 and 7 nodes
+	{277647, []string{
+		"0fc1f998e6fc1fa43a879cea4a54fe9947e02b925ebc46237a2406c50e0f07ea",
+		"d1e594eabe8c582dc01a8768cb01679aea6956165806f69f40e22e5e352b3bd1",
+		"d88bca3658a3ca6a2fe7fd2b1ad19da2793fcf24617003eacad813322035e5a1",
+		"5b633c585506eca654972b58d89c749f748a679d13c265d70821789d4fa93af8",
+		"d385205568e5420bc73b190ede001678730d42744d0716d2c5c2b6467cf73082",
+		"20b15adf16076448ee3a6f818ee5fde37268a4dde394c2cfc0eaef1f432026c0",
+		"54d3c39b4726ea0eb8e8ccbd9323d329319126b29adab03e475805e069d96d98",
+		"32e74324248d723870bd840f142868e7cb0aeaae4898261dd90fd57ad47fddaa",
+		"e013f36ad058e75c0decf0c000a4cee9472b39a6519b7efb28c9984d0f7c2a8d",
+		"1ea23d29ebacdf4717f6358f9a7ef04a07a1eea7eab89fe2cf5d68e468cb30d0",
+		"5143ba5524d21b646de5cd5a1ab6ee7b7823a59c87a347d3b5339e9f977e7dcd",
+		"d73727303fab976be2ea94aa9cfdc17a1e13d9f248dd57afdb8a2c62bf97f3ed",
+		"1571a57f5306f864d14abe6a42c1b7bb06196d2fe812726dfef3a5792d43dd56",
+		"47f63fb85f9132049347eafa69fd643816b79203c44df55f1607ce3fb2c79674",
+		"d1c908bfbde3bce761cd1e3993fe00dfb50d123eda8ef6ef45e9d2cbba9911d9",
+		"96bae5f279085a96b85e7fb52376aef5663faef09c985de2627ff8541495dd51",
+		"b66cfa2a3869520eaf91b4c1b1183457f953a94077c377b87aa1e19b92ac28ee",
+		"0e84d3943b820cae0bba46ada5979211822718d7e3359393e2d8684f463dcb2e",
+		"cecced2353c767822d46733c41950ffaa5501fa897221b9afb93dc04463984ee",
+		"88da346424a767324612397b3f8d36b532e3411defa23e04e5e85c9e03bb2c20",
+		"f8bf188f5b5443c564104dcc2d1bd531efb61b0b5d08173e24932d5cde632653",
+		"db4524b3c479ca50b0b8d6f5d83a3afa2006333514f0941cae042806af02290e",
+		"a331391e2a4a0fd54d2e8f77c5b4192ac152bc648172e583fb8f24359507d0f1",
+		"7970a7a63a240e5a2729d1af8d6277b33670a32f9a7104686ce1d30960c0acb9",
+		"63696fb8e629c01b96fcaf641aa115f90be74bb732ba25834c47146edab65b16",
+		"4fd5c12da8df697019c08d6440422328979b5ff9446ea260823e6ef192353284",
+		"9c0f359dfd7d85e9495db6a391205b502c58c1e3ce2fc92fec8ce039950d99f1",
+		"49f9e6d12f280a7d8809c05f47666926cdfe1b8de3a4834e987046e76f6fb2b3",
+		"7e07657467c2a914a81d00390c471b5ece3cbd498ad54befbd46948d7ff4cc6a",
+		"121bf4593cd745287e0435a24f962128e808ea2787c621e99b8d03c01661cfdf",
+		"4533c100601432bad099bbf9dcf02e055cc9d9f4daf7911d656eac0d15b835d0",
+		"ecfb8cf3708b67a84349b22ecce13e5a361953f2e8621a30fce45bf420251a6a",
+		"ff5962c9cedd2b99c7b14760debbcc3d30761b96da96a73703719b3c97c7de28",
+		"ef4848e49f7fe8a822879f102f2e30e894a0beae825b473942250e91d3486b8d",
+		"11d9eda322d16a442d516867b0afc5b156dcfee0641e0bfed02fbd5feff99d82",
+		"4f6e29fa5679e7fe7b43c091fa45c15f748b3f6e4b8a4b0bcc754d2f40db8164",
+		"b8477d8492176628f4feb39064c32ebf4a5fc1db1fe20bda716a473fc45ca181",
+		"58d725cef54e9fc6c6117df0fd7f5e942b886dc3395bf32c59a2117116e46bac",
+		"eb147ad0d2900c7b8a21886ccf3ba4ebceda71cf1afd4ec20945bc3d560628f0",
+		"8ab1fb5d31deb41acd02f7019a2fff4809ff183a7480ea0ac014f003765d870c",
+		"f84038fe40a241b72e72fab44f3dae55f124c92ae437ff1330f35aa92393f79b",
+		"cc96b595cb7aaa28d91a669ab810c92fa03c14269a9c07e524876f58c7d89ccc",
+		"2891e29d49d44f276153a6fd67ce5988e3a0d531ddb58c4f724be8ed591b9ace",
+		"b9fcc3d4365b1b43c7f8fe07d48628f37f9d7241926a3d02f130a9a0af5845b9",
+		"08cee5accb51e6b8917a16d3b1f4edce83acf100a56b61c4c66d9240ead1c31a",
+		"ca512010928abcdc1919089ba4f60ff87315c6c30046d4844ea4d147f2d24794",
+		"3ea8b0682b62a08129a6074b4d8902bb0a4dedda307c09c3a32634120b1fe3f6",
+		"b28d265810f9d8e586667c83ef17c585508715398e14539549ab6ce3e9f11d8a",
+		"e9438b09def39a973d7ef8191103313062bbb7b66aa9b356083d59dc6ac5cee1",
+		"45e450396d50dfdaf6a794ffa5035276deb7eb01fcb6f47bc9e1d328b185db3c",
+		"13663b6c748c1016d9df131a18839860d4d22d2dd200b4915884b77877c8be29",
+		"3714385801a41390abb07076c9f229afee408b65755c22ce638d393fec553cd0",
+		"dad97c199f65fde4d0d363f6d61319bd8ba581517d59b64437176a7e3363b846",
+		"fcd3b1b96e0fc512209e6c0f7217037bbfa30930c1ff0a7a3b64c19b2e12e6e2",
+		"a58c7f5e4562e93ae63283b63f986b821d07ee34892a617160a6f0ac61f8a070",
+		"177425dfbc1d23c12ecebcbc8b9b1c084f0deb6abcd550e9c3173f7073615fec",
+		"b13ab5fd1df285e4b61d79a493269edc67e3b04a4339269664630a175c86d422",
+		"b8aa1aa4d00a6cb38f57c693256204aad90b095b520fc2af129c7248cbc71af7",
+		"38d197d90cc3c4b27814606790f51fa8559554fcc4cee13702d617e4b8d2d04d",
+		"8d0d822c6787b08a34239732e64ddf209dd430406d4c178625b251d9364b7cd6",
+		"fd49ecf19b664721b302843da687e1a7fa7a157ec7eedca5c8861037c17b1e0e",
+		"3f7ce03e3077c93602d70a06a1a1ad6f61db508c31c45998b894182cee3d1c32",
+		"a1ce51fed3b68d0c1abf84fef08f8647efbd80a9a4dc286367d219cba467de84",
+		"6c8c9a0c383db9834ac83afdab009f30d67abfd2bafb6cfe3248c7af0be0777a",
+		"1c33d4f3d8d7983270d7a54a941e2b8bdc8176e00530c130a3bcd802ef587a1d",
+		"658e7ebc66f4665c5fe9f969441666f459796d91fc8a9c47846be47c86400e10",
+		"2ba4df07c62cf1ae8376a5e8a51813f21b8d462f65766ecf995287f450017735",
+		"b6828cbfb13d2bd0d93e103cf5074de725c464053ee166d7c56a5e9001c91b32",
+		"b263fbaaccaeaab6fe911f4948c28f06fa440d2f03405634173443d461b84d82",
+		"3cc23ae109a4a66e00e6bbbe0a732b4123497fbf178c09962e2d47989ffa2cd6",
+		"14fa7fe0cdfc6ebc2d83895d278e9595f2dc7929d1c6bf2ab9234b6ee2d785da",
+		"731f35e51deefdc6d4bb0e62f1acee4360ff9eb5fa47c9aa3332e388efb957b7",
+		"ade0cbd75f1403e5fdc2336b5937c3cb09a8c39abeebe442af82c0e4e14d1328",
+		"a0e9c43f01e075f56fe112b0d9b0fa895e88c69f4d5abc915b0d6349e882b165",
+		"a849f4e881a10c7d82224f80ddf3feab8909ab4548f304309e8ae9887a5463b5",
+		"f3806e92a2fa93752c457b2b37d227cb6c446931e5ba755974e064b0f2bcd607",
+		"4d245477496144976d7f94b6600640849ffc4c1632adb55f6426e2d6ee2ab5cf",
+		"05c137e71593a5ce4bfb39238259a17cd33605bcde38565116da9ec2e204010b",
+		"fb68e0866a96598abb187344fccb80139f57fbba494e146bb8e17311a18d10bf",
+		"695456f006a8092bc5c78f40d49c345a61ad306db2c58e086389814ca41686d1",
+		"02e3ab56de225fee3ca82bb55299a79f764477662e1b74b0f83771d80323bf67",
+		"1e2a71e42a07c5487e1a1b97fbb9a459d189fa2d15b282edfa70365c29c03bfa",
+		"0b96bfec234f38de540420089a4489282d3f7a37e4ed64588ae753cd834f77c1",
+		"f4907b7d3f637ece4ab3e63432b00210980480b830ebd8e101564f1def73b947",
+		"79c5b49c0a2811fd6db3081e3360fe9fafc726138ff385e81404eb410b0dcdee",
+		"7f5286c2d64c0a51c7d0e40ecd568a2ff6cc44e9b282d483258ea48973fa2be5",
+		"1d1ea5c277b965644dccadfc6c0534dcdfbe9a7ead9591382906ca5ff463cb1e",
+		"c4b5caab63912276bff3d97b825bb3af372231677ac02f118f0eaa50b49080b3",
+		"40df0a3d2186bdebf6405ab857d9394839c6c87357ec8ab252e0f639355694ab",
+		"6f10b01af3d68d9f5158567bb9c0be81cb718593dac0c44ed230a4488c379325",
+		"474a8d63bfc3152561618433dff8616272ab20a484d9e2689a0eb0a1438495a4",
+		"819d15d3ded042917bf5dc54a7be1216b6c19d6c8bb9fa8fdc0911b3ed1c5a8d",
+		"226035c24ebba5de8693050702eeedf6d5527a3ea4d2ed009db6677ad5dada76",
+		"3ef9a5dc951a041d978bbcb61b6c475037970d7ce30ac49b816533b16e9d76e0",
+		"432767df731c1b7599dc1cf9c9368eacdd3333cc2812875e3f50bb94992178a7",
+		"589b002476418d05db3051de881261f0a903a1dfca98cff425d6bfa28ef8c8df",
+		"44865d5e1775245e09a3a7dae4c1431b0e09e39299746e767bd862c32559511a",
+		"09c8dec0eac1e8bd3b7c523f0ebf3f4c0726c61aa0e1e77089e62b80c034d930",
+		"c104686d491edd2e77d4d90c96b8f3ac718f8e6530e58f09cedbda138b9077e8",
+		"02753a715c403da342218f6029c6d764b6526c8eaa293b299b7f9e4ca18a79e5",
+		"9c7df2a73cbac3218fe895167800f0312f21d624c02ebec634c4b4f28db74174",
+		"5754d6618e69aa077dd1b4204c637c5c8f46e70b49ab62bb4fc5f50251b62610",
+		"d588b0a220319a18f37c1f22220e8288726e97df460ed95ed5681f31ce23515c",
+		"98db26d243e5550063b8c081a4e0664ec8bd0eb6af2963e60615a6007b455c94",
+		"edd6c2e77ea20caaf8b3bfaf696277231af069c1cf229cc6b5e114915cfeeae2",
+		"4e8f83da28c4dfb8a9d78959fa843fc587dff928fafcae78776778be7dcfc754",
+		"eff22e4aa8b24b2dd8afa41b7ecedcad7cd40012cdaea260dd3e3dbc35067be2",
+		"e9aa7f9c8238fd5e53c60f7d91284af188a6d834afeea353d145f4f827d775b5",
+		"6eb8fceaabff9a7bf70bbbca4c92ea7b921ddec986b19e8bf783a3fe743b9173",
+		"e40b420a63fdd3f4e9451748e3c14dad02d0a0a0578a605e81d9314281abbf02",
+		"e9a896cf634c1db1964fd55382ef20486c1134382777ab29d91d20ccdf22d5db",
+		"55baa9412d949bfadbd2a4adf6972f4ca1d120eef2a75381458e013810397095",
+		"4c48074ddfca799df7f80d4e2ae60c962c0b4370c03c485e9e6ef565de5df76c",
+		"59f451f847669b5b08abf6b6b7bd026a28b501f9522412ef92187460b61b15b6",
+		"c60cd29f8c7e1a8efe41e0c1523a8c068b7b146463662a4fd6d92ca16aea8adb",
+		"89ed354304a33fff2eccd043e0879564a04d21ef050f69d7c97648caa1939e07",
+		"f532db227195af14a769df6711bf220968d7dc05c41c9a15fb8ff6b2085eb348",
+		"c39ae80841c47df4da70b0beeeae59b23706ff6f28ed2d0d63ff3fc3440e78a4",
+		"b7d36b797bfc5091c923755f648dc7bbcba1210885432c9bdc41c0513dedbeb6",
+		"f0f276e6bcf4aafcba18a042a99e93ff669cba58a77356412edb640cce55f47f",
+		"5cbbbbecd69012b8c7523b8e7681e9a628f79e75ed07f329338a06b64b178bad",
+		"4e192c91d6f14f74800b66e8267b14b51e133227a7eecf2b908334b96662b588",
+		"8faabbcecb157cccfbcd87090f6459216151c0de8ac561e7412541fc473cd07c",
+		"79e3db71c3a60fe293583b584919d3417d7df4ffb24f0c5b5697534e9de61586",
+		"0cbc485daec370909a15b6b3ddb16fb3ec17aa3fb86479fe006879cb413a701c",
+		"a7f14316edcca2996eb7a1069cf2736599ca2fad7c4f9a5248903e7e2a876d5e",
+		"5bf126d845ad5031f5f1304b6ae5af9497f1def2b7b668f680ff259214400297",
+		"75c0e083511f7052f11795885fc7c7dc6c69a01654f2d95eee0004d8f145b57c",
+		"480c0b949bfa12d6553995e23fef20e64ee097695936c9f8892ceb1245b3bb60",
+		"6a003d326577e61838609ecc9b1812b1442bc83673f019b56cf930395ddaa625",
+		"c8b73a49361c84743a26a700df293e6f80b096d4b55da571178bfb0c88e6cdad",
+		"57249137463225a3a802f5c0aaaefe7aacda582d776d4c8ca7d1781766827155",
+		"e64102fe28e3180e74a39087a6a2a41fc8a2abb6aff95c583ec0137af58a2131",
+		"5efce0f819cf8b8d0bbeccb696b94a212df947abb7d1d579b106e9ff10948aeb",
+		"9a3e286a6c98eb4599b32834bda8f4eafc94e62bde4e7c5161ba462f10bcb94c",
+		"010aa178b4fea5d884c80602d61b5e67a61ef3e03f501c03b6c922cc5eccf1e6",
+		"a50f9ddb51e03010c9722b2615329596e21364b19d63629114a214717607fd74",
+		"3567cffc7893aaa5e1418b1bc0ce122ec43804a1fe18f3c83e609a1bd12c838f",
+		"1fc0861ecd37e4850ffeeb2b68d861abbb704d528619134d67c57a88c212c4a1",
+		"c5e472649487c1f3fd5b5badaee311b1189fd3b30b2cb387063cc6264d5d5754",
+		"12052c31ab26552323b62cc14a5845c4b9e2a2a7d38d333d48680c1034d012bd",
+		"f7ae27bcc51f89b647ac2347a1a2e2cbfd0b8290537b054c5ef236b896511208",
+		"f5d4257aa840e6d4d0e06643c509c00ebfa41dd120f1b001076464a0a421c579",
+		"625f20f6820c1e0168e552c74375a39d295d015d39fd9fb1701c3fac0f589643",
+		"ac7aa1ff49d4320422dd0114b9adc9fe165d0196389b6def5b2cc9282768fc81",
+		"e92f94c250f575b673425817bbe3f76ce8b2f20bfb396dbaffddcff649cb3f2f",
+		"e25ba9a5bdb69d37f954ef30cc739806801591584b0b35fdedc75f2e372a64d4",
+		"7a5323cf1eec832bd28a0bf886429690d5bd17c83252a853c004c92f2d6dcd8d",
+		"559eb774997fd6ec079e0f4e9efa5ce5e07928ed3d207778e40c9461bdd2b15c",
+		"3400004a1e92dedc8d4b89ae80f14cab36476d80c4ead59faeb9c390e4cdb71c",
+		"621c7804b2dd9a695a20a4d6a33d7f0e3dbce30badf8bb0a45b090b8c0a10df3",
+		"308f81dfd5d226a7bd7627e688dd691bfabe05181156162ad00af2bd2395141c",
+		"20fef83aa329818c123aa1173e30dcb573ae79269403a2dec368a6d55bb2f592",
+		"cfbc35d15439b077a01b3b62f2dbe63bd63d4e6449c6358ea527f0b9cfa482b1",
+		"0d8055a6bfbd3c24a862df6d4325917fed8bf921c6300d39d061a8e8c5eb2174",
+		"ad0f431b2c93cede10af5b457da96d320178c2ececc2debe5dafecb19da18424",
+		"d45172a7599267f2724c6477650a273cddb289d4b041e6efc8820d20b84ac88e",
+		"703d9e011c8a5223b0853ff1737f99c5c6f84cfd8becf5610d3ff1e9efbbfbdf",
+		"d2da3c6fc7f570f296ed6f47b3dce62e57971bf821295973529c96367503658b",
+		"53b06cf8be567047b7d6b30384e0c85c87f66dcc2f21e4058030ce4fc8899703",
+		"1c33c1720fec861a3eb9beefba83b3de8f1d3208196662c3329da94aca27d6ae",
+		"01ddadf0ec02cb51c34661d11d502f667927f8a089cb76d36b5caf02fca3187e",
+		"88ac28491563d03b27c535965a0da0ee45c3f92faf1541f5c2e67e4e3935cab4",
+		"1953dade5e6ad9e2c726c7f1f0859f4a720df5668223d8e22671072af44d6682",
+		"c61695b394ae448dc9d68d62ff781aaaa3ca9e0a18240ca994ff0d70c1ed87b1",
+		"7c9a168f8604276605a7dc104b1fbb484c16a3cc4e5fabf29bb05e16a20d5eb1",
+		"ae24e7070124434ecb9957b7e80c38bec00e35a3b4dca9f5296b76afe2ca9e26",
+		"b83bd5711793bc632701cd88277b3aca30b8fb7ff0974a0c61acc30c5ea75352",
+		"aab06d85f77d87cb0db3d4119ade82aa6b9062bb7a58266fcbc3caa36223bfe9",
+		"06b5bcdfdfc61029e08e0a50e865d63386d9c7669d1c667f2151a5e84ca4a955",
+		"0f9571e81be149c9d122a14da32959337918bcfd3d299af6bde96790da606d18",
+		"cabe0212a821835bf3c09fe7389498fbe88be0f48dbfe4831278991557f69d65",
+		"46e1e112e0fa2ad022bace2eb78758aa2928ea0afa373f493f6ef223bd986933",
+		"7492db89b70bd999173aad41dabcec95399ecbbfa664190128c4a3550840adfb",
+		"04e96f0035151093b6b985e86cbfdb056b49d4241af6f4a648682641cd15fbbc",
+		"ca4b3083997c86ee25bf4ba30ed8ffcbd0592a3d25475a1a0d9b599655260fd8",
+		"c2178728ddee17efeaa1044125bc405206dceca80fa9bf5e9b3691ebd8ae5ff8",
+		"efa729517801da7b54879a86adfe7ed9fc2de8886060fc40aba91680d04d95cf",
+		"ad00ff71b6b8e901e49d7335246be9b2d99b88f904c783de511eacecd7fac497",
+		"b984693dfea6c6b526f8786c4101385cbe4f482306dc219d34be4a6468cee1d3",
+		"7271dfeef69f74ed6919bf6bce3de615a7f01fb8b88b4fcecdca137b0ea61ee2",
+		"3955e4d68415d13c654425b02e95e4439bfd18865f0f4cbaefa57c1e16025f05",
+		"97722ef619c4b33b3ed178b79dfe27359a598ca1444295119f512d8a8fb5f704",
+		"f1847d53b871205cb26299628b736929b7d2ddf17a7df00367b7793767f9debf",
+		"5f92efac1131676fa6cd5ffe968e9e3672d4560430968c06e828ca2970f8cb56",
+		"30eaeb52ee2f34954d4c2399b292c0bb279377ac17bca12c38601665b2f200e5",
+		"c73e13fb2b604e7a1f6e3481498437d7ca4e62979ce5b67edead1ac1ed5e228d",
+		"653e7b17d1385591f14bbd66247c26b8e88f900e5c81fa4596a9eb1c40a7ae25",
+		"4341db15a445d6279ff9e45d1da26b0e2fc5e47e7ce395c944c8f382e0660494",
+		"50b48db10fb5c69f11d4300e24e4d6085c962d52e06deb79e5534a2df8b35056",
+		"707569558d73067f2093b8b5202d2e3facba983fc23e52f8718c5d5ca49b4203",
+		"29fea2c8cd684b1e16be86006accad60472c9addf1815bc77ac0b5acc0a52fb9",
+		"bb0008275b4118cca985634a961188c63d70a779e32fcacf17065e49602e4231",
+		"062042097d67861bd0157e92421d45e8395286c4cc00cf96c1e7a3e6e5df1998",
+		"366bb22e38df378ccb58a88a618df2533d84f5cd5c31f96216a228e621dcea74",
+		"1399db8be0e85e01a904a633d52471a9b35457b3d9defc5d591ff0f73e88d1df",
+		"a2e3c152a692fb58eed9b06e8d3e042f8c0fe5b8da7164abb6db1fbb8536e78e",
+		"31060acfd0e06d052c56b8b57a5de8ab78cc7b413c7de5d3bb348e711c44d4c7",
+		"e7a3e769da41ed50418d321ce379dade9be6f4a4bea19dfbe9052d1827b63ee2",
+		"8ffc9b8f653b15edf64c0905e81fbd85686a8e5dc146623ea6685ba78a888799",
+		"6040d3bb4831344d49f5a94a71a9f724abff29b4d35d1a931169ebff45507dd3",
+		"4fe75a843d48487a235528af214c678d2108fea5a709d53c2116e3a77d6a2fb5",
+		"116fe94cb00c2a06ffd58726f34801fc10c934d8324d4e7b4d9d1450752565a8",
+		"7093861b447670aa73788509bed42dda0dc976be7697a0ae0d5cc53a5e8a941b",
+		"3a266953259bf98f8ad2740683d132b5c5031ffa17ae7a7ad4f01defaeb6c394",
+		"911c8e27913719aef0e45e45c1a972ccdc0b2265508aa8604d9ce76c1db295ca",
+		"f1b00d5cc08e9804d8312cd736a7b3057ebbaae84e785617ddb34317f1fb0ae6",
+		"a2239e915408055fd99f25860d0de4dae71cfbf36ba1d39ca81ca2b91d36532e",
+		"e351ac01c68633239eb0bcd17ba47f5e715d8fb19dad7f88bbe7babed03af33e",
+		"712db987272743dd6e02bdd00fd8a0718bbfd04d13495a97eb70cdc42c010b1e",
+		"d2eaf36ee0947704f830d104ed39534afe2fa82f41d3aa9c2f6e7e6993ff792e",
+		"8c8eda47dc931dc5c79e352a976ee6e476f4d30b709014b10183ec10e8a26d67",
+		"19808b177b72ec2e7043bb5ac468b7e6e90085853d1c5051788d522a11223ce6",
+	}, "36ac31298eb05c23be1f775d635104705e4560c6532b95c158023c6dc9af06c3"},
+}
+
+func TestBitcoinBlockRoots(t *testing.T) {
+	for _, block := range btcBlocks {
+		txids := decodeHexes(t, block.txids)
+
+		output, err := DeriveRoot(txids, "SHA256D", Bitcoin)
+		if err != nil {
+			t.Fatalf("block %d: (err) got %q, wanted nil", block.height, err)
+		}
+		if hex.EncodeToString(output) != block.root {
+			t.Errorf("block %d: (out) got %q, wanted %q", block.height, hex.EncodeToString(output), block.root)
+		}
+
+		// real blocks are not mutated (CVE-2012-2459)
+		if mutated, err := DetectMutation(txids, "SHA256D", Bitcoin); mutated || err != nil {
+			t.Errorf("block %d: (mutated) got %v (%v), wanted false", block.height, mutated, err)
+		}
+
+		// the txids are not modified by the byte order reversal
+		if hex.EncodeToString(txids[0]) != block.txids[0] {
+			t.Errorf("block %d: txid modified: %x", block.height, txids[0])
+		}
+
+		builder, err := NewBuilder("SHA256D", Bitcoin)
+		if err != nil {
+			t.Fatalf("block %d: (builder err) got %q, wanted nil", block.height, err)
+		}
+		for _, txid := range txids {
+			builder.Append(txid)
+		}
+		built, err := builder.Root()
+		if err != nil {
+			t.Fatalf("block %d: (builder err) got %q, wanted nil", block.height, err)
+		}
+		if hex.EncodeToString(built) != block.root {
+			t.Errorf("block %d: (builder) got %x, wanted %q", block.height, built, block.root)
+		}
+
+		tree, err := BuildTree(txids, "SHA256D", Bitcoin)
+		if err != nil {
+			t.Fatalf("block %d: (tree err) got %q, wanted nil", block.height, err)
+		}
+		if hex.EncodeToString(tree.Root()) != block.root {
+			t.Errorf("block %d: (tree) got %x, wanted %q", block.height, tree.Root(), block.root)
+		}
+
+		root, _ := hex.DecodeString(block.root)
+		for i, txid := range txids {
+			proof, err := GenerateProof(txids, i, "SHA256D", Bitcoin)
+			if err != nil {
+				t.Fatalf("block %d, tx %d: (proof err) got %q, wanted nil", block.height, i, err)
+			}
+			ok, err := VerifyProof(txid, proof, root, "SHA256D", Bitcoin)
+			if err != nil || !ok {
+				t.Errorf("block %d, tx %d: (verify) got %v, %v, wanted true, nil", block.height, i, ok, err)
+			}
+		}
+	}
+}
+
+func TestBitcoinRequiresSHA256D(t *testing.T) {
+	if _, err := New(WithProcessType(Bitcoin), WithAlgorithm("SHA256SUM256")); err == nil {
+		t.Errorf("(err) got nil, wanted an algorithm mismatch error")
+	}
+
+	ms, err := New(WithProcessType(Bitcoin))
+	if err != nil {
+		t.Fatalf("(err) got %q, wanted nil", err)
+	}
+	if ms.HashTypeID != "SHA256D" {
+		t.Errorf("(algorithm) got %q, wanted %q", ms.HashTypeID, "SHA256D")
+	}
+}
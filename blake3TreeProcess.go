@@ -0,0 +1,93 @@
+package merkletree
+
+//
+// BLAKE3 chunk tree process type.
+//
+//	- Data: the input split in BLAKE3ChunkSize (1 KiB) chunks, in order (see BLAKE3Chunks).
+//	  Every chunk but the last one is full; an empty input is a single empty chunk.
+//	- A chunk is compressed into its chaining value with its index (chunk counter),
+//	  nodes are BLAKE3 parent nodes, the last compression is flagged as the root.
+//	- The tree is left-balanced: the Pass Through (RFC 6962) shape.
+//	- The root is the BLAKE3 digest of the whole input.
+//
+// Inclusion proofs are not supported: a chunk's chaining value depends on its index,
+// and the root is a differently flagged compression, which VerifyProof can not replay.
+//
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yveshoebeke/merkletree/internal/blake3"
+)
+
+// Size of a BLAKE3 chunk: the leaves of the BLAKE3Tree process type.
+const BLAKE3ChunkSize = blake3.ChunkSize
+
+func (ms *MerkleService) processBLAKE3TreeRequest(ctx context.Context) error {
+	const ThisProcess = 7
+	contextProcessType := ctx.Value(contextKeyRequestID)
+	if contextProcessType != processTypes[ThisProcess] {
+		return &InvalidContextProcessTypeErr{contextProcessType.(string)}
+	}
+
+	last := len(ms.Leaves) - 1
+	for i, chunk := range ms.Leaves {
+		if (i < last && len(chunk) != BLAKE3ChunkSize) || len(chunk) > BLAKE3ChunkSize || (i > 0 && len(chunk) == 0) {
+			return &ArgumentErr{fmt.Sprintf("chunk %d: %d bytes, chunks are %d bytes (the last one at most) - ", i, len(chunk), BLAKE3ChunkSize)}
+		}
+	}
+
+	// Single chunk: it is the root.
+	if len(ms.Leaves) == 1 {
+		ms.Leaves[0] = blake3.ChunkRoot(ms.Leaves[0])
+		ms.recordLevel()
+		ms.ProcessResult = ms.Leaves[0]
+		return nil
+	}
+
+	ms.growNodes(len(ms.Leaves))
+	ms.shard(len(ms.Leaves), func(_ *MerkleService, from, to int) {
+		for i := from; i < to; i++ {
+			copy(ms.slot(i), blake3.ChunkChainingValue(ms.Leaves[i], uint64(i)))
+			ms.Leaves[i] = ms.slot(i)
+		}
+	})
+	ms.recordLevel()
+
+	for len(ms.Leaves) > 1 {
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
+
+		// - the last two nodes are the root's children
+		ms.pairLevel(0, If(len(ms.Leaves) == 2, blake3ParentRoot, blake3Parent))
+		ms.recordLevel()
+	}
+
+	ms.ProcessResult = ms.Leaves[0]
+
+	return nil
+}
+
+// BLAKE3 parent node.
+func blake3Parent(_ *MerkleService, dst, left, right []byte) {
+	copy(dst, blake3.ParentChainingValue(left, right))
+}
+
+// BLAKE3 root node.
+func blake3ParentRoot(_ *MerkleService, dst, left, right []byte) {
+	copy(dst, blake3.ParentRoot(left, right))
+}
+
+// Split data in BLAKE3 chunks: the leaves of the BLAKE3Tree process type.
+// The chunks are sub-slices of data.
+func BLAKE3Chunks(data []byte) [][]byte {
+	chunks := [][]byte{}
+	for len(data) > BLAKE3ChunkSize {
+		chunks = append(chunks, data[:BLAKE3ChunkSize])
+		data = data[BLAKE3ChunkSize:]
+	}
+
+	return append(chunks, data)
+}
@@ -0,0 +1,53 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestBLAKE3Tree(t *testing.T) {
+	for _, length := range []int{0, 1, 1023, 1024, 1025, 2048, 3 * 1024, 5*1024 + 7, 8 * 1024, 31*1024 + 1} {
+		data := make([]byte, length)
+		for i := range data {
+			data[i] = byte(i % 251)
+		}
+		expected := BLAKE3(data)
+
+		output, err := DeriveRoot(BLAKE3Chunks(data), "BLAKE3", BLAKE3Tree)
+		if err != nil {
+			t.Fatalf("length %d: (err) got %q, wanted nil", length, err)
+		}
+		if !bytes.Equal(output, expected) {
+			t.Errorf("length %d: (out) got %x, wanted %x", length, output, expected)
+		}
+
+		tree, err := BuildTree(BLAKE3Chunks(data), "BLAKE3", BLAKE3Tree)
+		if err != nil {
+			t.Fatalf("length %d: (tree err) got %q, wanted nil", length, err)
+		}
+		if !bytes.Equal(tree.Root(), expected) {
+			t.Errorf("length %d: (tree) got %x, wanted %x", length, tree.Root(), expected)
+		}
+
+		builder, _ := NewBuilder("BLAKE3", BLAKE3Tree)
+		for _, chunk := range BLAKE3Chunks(data) {
+			builder.Append(chunk)
+		}
+		if built, _ := builder.Root(); !bytes.Equal(built, expected) {
+			t.Errorf("length %d: (builder) got %x, wanted %x", length, built, expected)
+		}
+	}
+
+	// chunks must be full, but the last one
+	chunks := [][]byte{make([]byte, 1000), make([]byte, 10)}
+	var argErr *ArgumentErr
+	if _, err := DeriveRoot(chunks, "BLAKE3", BLAKE3Tree); !errors.As(err, &argErr) {
+		t.Errorf("(err) got %v, wanted ArgumentErr", err)
+	}
+
+	var unsupported *UnsupportedProcessTypeErr
+	if _, err := GenerateProof(BLAKE3Chunks(make([]byte, 4096)), 1, "BLAKE3", BLAKE3Tree); !errors.As(err, &unsupported) {
+		t.Errorf("(proof err) got %v, wanted UnsupportedProcessTypeErr", err)
+	}
+}
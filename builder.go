@@ -0,0 +1,226 @@
+package merkletree
+
+//
+// Incremental (append-only) builder.
+//
+// Leaves are appended one at a time; the root is available at any point.
+//
+//	- PassThrough, RFC6962, DupeAppend, Bitcoin: the builder only keeps the frontier,
+//	  the roots of the complete subtrees not yet paired: one per bit of the
+//	  leaf count, so O(log n) memory and O(log n) hashes per append/root.
+//	  Root() folds it as the odd node policy says.
+//	- BinaryTree, Monero (and custom process types): pairing starts at an index that depends on the final leaf
+//	  count, so no node survives an append. The builder keeps the leaves and
+//	  Root() builds the tree from them (O(n)).
+//	- BLAKE3Tree: the last chunk and the root are compressed differently, the
+//	  builder keeps the chunks as well (use blake3 streaming for large inputs).
+//
+// Roots are identical to DeriveRoot's for the same leaves, and so are the
+// MutatedTreeErr of WithStrictMutation.
+// A Builder is not safe for concurrent use.
+//
+
+import (
+	"bytes"
+	"slices"
+)
+
+// Incremental merkle tree builder.
+type Builder struct {
+	service  *MerkleService
+	job      MerkleService // working buffer
+	frontier [][]byte      // frontier[h]: root of a complete subtree of 2^h leaves, or nil
+	repeated []bool        // repeated[h]: the last two complete subtrees of 2^h leaves paired were equal
+	leaves   [][]byte      // unless frontierOnly
+	size     int
+	err      error // first leaf rejected (see WithEmptyLeaves)
+}
+
+/*
+Entry Point (incremental)
+  - Builder for leaves arriving one at a time.
+*/
+func NewBuilder(algorithmRequested string, processType int) (*Builder, error) {
+	ms, err := New(WithAlgorithm(algorithmRequested), WithProcessType(processType))
+	if err != nil {
+		return nil, err
+	}
+
+	return ms.NewBuilder(), nil
+}
+
+// Builder with the service's configuration (one changed since New: Root returns the error).
+func (ms *MerkleService) NewBuilder() *Builder {
+	return &Builder{
+		service: ms,
+		job:     *ms,
+		err:     ms.checkConfig(),
+	}
+}
+
+// Number of leaves appended.
+func (b *Builder) Size() int {
+	return b.size
+}
+
+// Append a leaf. The leaf is copied: the caller may reuse it.
+// A rejected empty leaf is not appended, Root returns its error.
+func (b *Builder) Append(leaf []byte) {
+	if err := b.job.checkLeaves(leaf); err != nil {
+		if b.err == nil {
+			b.err = &EmptyLeafErr{b.size}
+		}
+		return
+	}
+	b.size++
+
+	node := slices.Clone(b.job.prepareLeaf(leaf))
+
+	if !b.frontierOnly() {
+		b.leaves = append(b.leaves, node)
+		return
+	}
+
+	// Carry: merge with the complete subtrees of the same size, like a binary counter.
+	level := 0
+	for ; level < len(b.frontier) && b.frontier[level] != nil; level++ {
+		b.repeated[level] = bytes.Equal(b.frontier[level], node)
+		node = b.job.hashPair(b.frontier[level], node)
+		b.frontier[level] = nil
+	}
+
+	if level == len(b.frontier) {
+		b.frontier = append(b.frontier, node)
+		b.repeated = append(b.repeated, false)
+	} else {
+		b.frontier[level] = node
+	}
+}
+
+// Root of the leaves appended so far.
+func (b *Builder) Root() ([]byte, error) {
+	if b.err != nil {
+		return []byte{}, b.err
+	}
+	if b.size == 0 {
+		return []byte{}, &ArgumentErr{"empty data - "}
+	}
+
+	if !b.frontierOnly() {
+		job := *b.service
+		job.leafHashing = false // hashed on Append
+
+		return job.Derive(b.leaves)
+	}
+
+	var root []byte
+	switch {
+	case b.size == 1:
+		single, err := b.singleLeafRoot()
+		if err != nil {
+			return []byte{}, err
+		}
+		root = single
+	case b.service.oddNodes == PromoteOddNodes:
+		root = b.passThroughRoot()
+	default:
+		padded, err := b.paddedRoot()
+		if err != nil {
+			return []byte{}, err
+		}
+		root = padded
+	}
+
+	return b.service.finalRoot(root), nil
+}
+
+// Process types whose root only needs the frontier.
+func (b *Builder) frontierOnly() bool {
+	switch b.service.ProcessType {
+	case PassThrough, RFC6962, DupeAppend, Bitcoin:
+		return true
+	}
+	return false
+}
+
+// Fold the frontier from the smallest subtree up: a partial right subtree is
+// hashed with the complete subtree left of it.
+func (b *Builder) passThroughRoot() []byte {
+	var node []byte
+	for _, complete := range b.frontier {
+		switch {
+		case complete == nil:
+		case node == nil:
+			node = complete
+		default:
+			node = b.job.hashPair(complete, node)
+		}
+	}
+
+	return node
+}
+
+// Same fold, but the last node of a level with an odd node count is
+// paired with its pad: [complete] [pad] or [partial] [pad].
+// Strict mutation: a level ending in a duplicated pair is rejected (see duplicateAndAppend).
+func (b *Builder) paddedRoot() ([]byte, error) {
+	var node []byte
+	top := len(b.frontier) - 1
+	for level, complete := range b.frontier {
+		if b.service.strictMutation && b.service.oddNodes == DuplicateOddNodes && b.duplicatedPair(level, complete, node) {
+			count := (b.size + 1<<level - 1) >> level
+			return nil, &MutatedTreeErr{level, count - 2}
+		}
+
+		switch {
+		case complete == nil && node == nil:
+		case complete == nil:
+			node = b.job.hashPair(node, b.pad(node))
+		case node != nil:
+			node = b.job.hashPair(complete, node)
+		case level == top:
+			node = complete
+		default:
+			node = b.job.hashPair(complete, b.pad(complete))
+		}
+	}
+
+	return node, nil
+}
+
+// Whether level ends in a duplicated pair: the partial node (the leaves past the
+// complete subtrees) and the complete subtree left of it, or the last two complete
+// subtrees paired (when the level's leaves are all in complete pairs).
+func (b *Builder) duplicatedPair(level int, complete, node []byte) bool {
+	if node != nil {
+		return complete != nil && bytes.Equal(complete, node)
+	}
+	pair := 2 << level
+	return b.size >= pair && b.size%pair == 0 && b.repeated[level]
+}
+
+// Root of a single leaf (see singleLeafRoot).
+func (b *Builder) singleLeafRoot() ([]byte, error) {
+	leaf := b.frontier[0]
+
+	switch b.service.singleLeaf {
+	case RejectSingleLeaf:
+		return nil, &ArgumentErr{"single leaf - "}
+	case HashSingleLeaf:
+		return b.job.hashPair(leaf, nil), nil
+	case PairSingleLeaf:
+		if b.service.oddNodes != PromoteOddNodes {
+			return b.job.hashPair(leaf, b.pad(leaf)), nil
+		}
+	}
+
+	return leaf, nil
+}
+
+// Node an odd node is hashed with (see padOddLevel).
+func (b *Builder) pad(node []byte) []byte {
+	if b.service.oddNodes == DuplicateOddNodes {
+		return node
+	}
+	return b.service.oddNodePad
+}
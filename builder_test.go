@@ -0,0 +1,92 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	for _, processType := range []int{PassThrough, DupeAppend, BinaryTree, RFC6962} {
+		leaves := makeIndexedLeaves(33)
+		builder, err := NewBuilder("SHA256SUM256", processType)
+		if err != nil {
+			t.Fatalf("(err) got %q, wanted nil", err)
+		}
+
+		var argErr *ArgumentErr
+		if _, err := builder.Root(); !errors.As(err, &argErr) {
+			t.Errorf("(err) got %v, wanted *ArgumentErr", err)
+		}
+
+		for n, leaf := range leaves {
+			builder.Append(leaf)
+			expected, _ := DeriveRoot(leaves[:n+1], "SHA256SUM256", processType)
+			output, err := builder.Root()
+			if err != nil {
+				t.Fatalf("(err) got %q, wanted nil", err)
+			}
+			if !bytes.Equal(output, expected) {
+				t.Errorf("process %d, %d leaves: (out) got %q, wanted %q", processType, n+1, hex.EncodeToString(output), hex.EncodeToString(expected))
+			}
+		}
+
+		if builder.Size() != len(leaves) {
+			t.Errorf("(size) got %d, wanted %d", builder.Size(), len(leaves))
+		}
+	}
+}
+
+func TestBuilderStrictMutation(t *testing.T) {
+	// leaves from a small set: duplicated pairs at every level, trailing or not
+	digests := makeIndexedLeaves(3)
+	var leaves [][]byte
+	for i := 0; i < 64; i++ {
+		leaves = append(leaves, digests[(i*i/5)%3])
+	}
+	repeated := slices.Concat(leaves[:7], leaves[:7])
+
+	for _, processType := range []int{DupeAppend, Bitcoin} {
+		ms, _ := New(WithProcessType(processType), WithStrictMutation(true))
+		for _, data := range [][][]byte{leaves, repeated} {
+			builder := ms.NewBuilder()
+			for n, leaf := range data {
+				builder.Append(leaf)
+
+				expected, expectedErr := ms.Derive(data[:n+1])
+				output, err := builder.Root()
+				if fmt.Sprint(err) != fmt.Sprint(expectedErr) || !bytes.Equal(output, expected) {
+					t.Errorf("process %d, %d leaves: (out) got %x (%v), wanted %x (%v)", processType, n+1, output, err, expected, expectedErr)
+				}
+			}
+		}
+	}
+
+	// [1] [2] [3] [3]: the builder carries on, its root is rejected until the pair is no longer trailing
+	ms, _ := New(WithProcessType(DupeAppend), WithStrictMutation(true))
+	builder := ms.NewBuilder()
+	var mutated *MutatedTreeErr
+	for _, leaf := range [][]byte{digests[0], digests[1], digests[2], digests[2]} {
+		builder.Append(leaf)
+	}
+	if _, err := builder.Root(); !errors.As(err, &mutated) {
+		t.Errorf("(err) got %v, wanted *MutatedTreeErr", err)
+	}
+	builder.Append(digests[0])
+	if _, err := builder.Root(); err != nil {
+		t.Errorf("(err) got %q, wanted nil", err)
+	}
+}
+
+func BenchmarkBuilder10000LeavesSHA256SUM256PassThrough(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		builder, _ := NewBuilder("SHA256SUM256", PassThrough)
+		for _, leaf := range tenThousandElements1 {
+			builder.Append(leaf)
+		}
+		builder.Root()
+	}
+}
@@ -0,0 +1,217 @@
+package merkletree
+
+//
+// Consistency proofs (RFC 6962, section 2.1.2).
+//
+// Functions:
+//
+//	- ConsistencyProof (ConsistencyProofContext):
+//		Proves that the tree of the first m leaves is a prefix of the tree of the first n leaves.
+//
+//	- VerifyConsistency:
+//		Checks such a proof against both roots (RFC 9162, section 2.1.4.2).
+//
+// Only available for process types where a tree's shape does not change when
+// leaves are appended: a subtree, once complete, keeps its hash.
+// These are PassThrough and RFC6962 (same shape, RFC 6962 adds domain separation).
+// DupeAppend (duplicates change with the size) and BinaryTree (pairing starts
+// from the tail) are not append-only friendly.
+//
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"math/bits"
+	"slices"
+)
+
+/*
+Entry Point (consistency proof)
+  - Proof that the tree of hashes[:m] is a prefix of the tree of hashes[:n].
+*/
+func ConsistencyProof(hashes [][]byte, m, n int, algorithmRequested string, processType int) ([][]byte, error) {
+	ms, err := New(WithAlgorithm(algorithmRequested), WithProcessType(processType))
+	if err != nil {
+		return nil, err
+	}
+
+	return ms.ConsistencyProof(hashes, m, n)
+}
+
+/*
+Entry Point (consistency verification)
+  - Checks that the tree of size m with root oldRoot is a prefix of the tree of size n with root newRoot.
+*/
+func VerifyConsistency(oldRoot, newRoot []byte, m, n int, proof [][]byte, algorithmRequested string, processType int) (bool, error) {
+	ms, err := New(WithAlgorithm(algorithmRequested), WithProcessType(processType))
+	if err != nil {
+		return false, err
+	}
+
+	return ms.VerifyConsistency(oldRoot, newRoot, m, n, proof)
+}
+
+// Proof that the tree of hashes[:m] is a prefix of the tree of hashes[:n], with the service's configuration.
+func (ms *MerkleService) ConsistencyProof(hashes [][]byte, m, n int) ([][]byte, error) {
+	return ms.ConsistencyProofContext(context.Background(), hashes, m, n)
+}
+
+// Consistency proof under ctx. The service's timeout, if any, is applied on top of it.
+func (ms *MerkleService) ConsistencyProofContext(ctx context.Context, hashes [][]byte, m, n int) ([][]byte, error) {
+	if err := ms.validateConsistencyArgs(m, n); err != nil {
+		return nil, err
+	}
+	if n > len(hashes) {
+		return nil, &ArgumentErr{fmt.Sprintf("tree size %d exceeds data size %d - ", n, len(hashes))}
+	}
+
+	job, err := ms.newJob(hashes[:n])
+	if err != nil {
+		return nil, err
+	}
+
+	var proof [][]byte
+	err = ms.executeFunc(ctx, job, func(job *MerkleService, ctx context.Context) error {
+		proof = job.subProof(m, job.Leaves, 0, true)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return proof, nil
+}
+
+// Proof that the tree of the first m leaves is a prefix of the tree of the first n leaves.
+// Complete subtrees are taken from the retained levels, not recomputed.
+func (t *Tree) ConsistencyProof(m, n int) ([][]byte, error) {
+	if err := t.service.validateConsistencyArgs(m, n); err != nil {
+		return nil, err
+	}
+	if n > t.LeafCount() {
+		return nil, &ArgumentErr{fmt.Sprintf("tree size %d exceeds leaf count %d - ", n, t.LeafCount())}
+	}
+
+	job := *t.service
+	job.levels = t.levels
+
+	return job.subProof(m, t.levels[0][:n], 0, true), nil
+}
+
+// Checks that the tree of size m with root oldRoot is a prefix of the tree of size n with root newRoot.
+// A malformed proof returns an error; a well-formed proof that does not match the roots returns false.
+func (ms *MerkleService) VerifyConsistency(oldRoot, newRoot []byte, m, n int, proof [][]byte) (bool, error) {
+	if err := ms.validateConsistencyArgs(m, n); err != nil {
+		return false, err
+	}
+
+	// Same tree: nothing to prove.
+	if m == n {
+		if len(proof) != 0 {
+			return false, &InvalidProofErr{"consistency proof of equal tree sizes must be empty"}
+		}
+		return subtle.ConstantTimeCompare(oldRoot, newRoot) == 1, nil
+	}
+	if len(proof) == 0 {
+		return false, &InvalidProofErr{"empty consistency proof"}
+	}
+
+	// Per request copy of the service (working buffer).
+	job := *ms
+
+	// If m is an exact power of 2, the old root is the first node of the path.
+	path := proof
+	if m&(m-1) == 0 {
+		path = append([][]byte{oldRoot}, proof...)
+	}
+
+	fn, sn := m-1, n-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+
+	oldNode, newNode := path[0], path[0]
+	for _, node := range path[1:] {
+		if sn == 0 {
+			return false, &InvalidProofErr{"consistency proof too long"}
+		}
+
+		if fn&1 == 1 || fn == sn {
+			oldNode = job.hashPair(node, oldNode)
+			newNode = job.hashPair(node, newNode)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			newNode = job.hashPair(newNode, node)
+		}
+
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 {
+		return false, &InvalidProofErr{"consistency proof too short"}
+	}
+
+	return subtle.ConstantTimeCompare(oldNode, oldRoot)&subtle.ConstantTimeCompare(newNode, newRoot) == 1, nil
+}
+
+// Tree sizes must be 0 < m <= n, for a process type that supports consistency proofs.
+func (ms *MerkleService) validateConsistencyArgs(m, n int) error {
+	if ms.ProcessType != PassThrough && ms.ProcessType != RFC6962 {
+		return &UnsupportedProcessTypeErr{"consistency proof", ms.ProcessType}
+	}
+	// (the RFC 6962 shape: odd nodes promoted, a single leaf is the root)
+	if ms.oddNodes != PromoteOddNodes || ms.singleLeaf != SingleLeafAsRoot {
+		return &ArgumentErr{"consistency proof requires promoted odd nodes and a single leaf as root - "}
+	}
+	if m <= 0 || m > n {
+		return &ArgumentErr{fmt.Sprintf("invalid tree sizes %d, %d - ", m, n)}
+	}
+
+	return nil
+}
+
+// SUBPROOF(m, D[n], complete) of RFC 6962; offset is the index of leaves[0] in the tree.
+// Proof nodes are copies: not the caller's leaves, nor a retained tree's nodes.
+func (ms *MerkleService) subProof(m int, leaves [][]byte, offset int, complete bool) [][]byte {
+	if m == len(leaves) {
+		if complete {
+			return [][]byte{}
+		}
+		return [][]byte{slices.Clone(ms.subtreeRoot(leaves, offset))}
+	}
+
+	k := splitPoint(len(leaves))
+	if m <= k {
+		return append(ms.subProof(m, leaves[:k], offset, complete), slices.Clone(ms.subtreeRoot(leaves[k:], offset+k)))
+	}
+
+	return append(ms.subProof(m-k, leaves[k:], offset+k, false), slices.Clone(ms.subtreeRoot(leaves[:k], offset)))
+}
+
+// Root of the (sub)tree of leaves, split at the largest power of two smaller than its size.
+// A complete subtree (power of two leaves, aligned) is a node of the retained levels, if any.
+func (ms *MerkleService) subtreeRoot(leaves [][]byte, offset int) []byte {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+
+	if ms.levels != nil && len(leaves)&(len(leaves)-1) == 0 {
+		level := bits.TrailingZeros(uint(len(leaves)))
+		return ms.levels[level][offset>>level]
+	}
+
+	k := splitPoint(len(leaves))
+
+	return ms.hashPair(ms.subtreeRoot(leaves[:k], offset), ms.subtreeRoot(leaves[k:], offset+k))
+}
+
+// Largest power of two smaller than n (n > 1).
+func splitPoint(n int) int {
+	return 1 << (bits.Len(uint(n-1)) - 1)
+}
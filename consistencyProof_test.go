@@ -0,0 +1,88 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestConsistencyProof(t *testing.T) {
+	for _, processType := range []int{PassThrough, RFC6962} {
+		leaves := makeIndexedLeaves(17)
+		tree, err := BuildTree(leaves, "SHA256SUM256", processType)
+		if err != nil {
+			t.Fatalf("(err) got %q, wanted nil", err)
+		}
+
+		for n := 1; n <= len(leaves); n++ {
+			newRoot, _ := DeriveRoot(leaves[:n], "SHA256SUM256", processType)
+			for m := 1; m <= n; m++ {
+				oldRoot, _ := DeriveRoot(leaves[:m], "SHA256SUM256", processType)
+
+				proof, err := ConsistencyProof(leaves, m, n, "SHA256SUM256", processType)
+				if err != nil {
+					t.Fatalf("(err) got %q, wanted nil", err)
+				}
+				if ok, err := VerifyConsistency(oldRoot, newRoot, m, n, proof, "SHA256SUM256", processType); !ok || err != nil {
+					t.Errorf("process %d, %d to %d: (verify) got %v (%v), wanted true", processType, m, n, ok, err)
+				}
+
+				// same proof from the retained tree
+				treeProof, err := tree.ConsistencyProof(m, n)
+				if err != nil {
+					t.Fatalf("(err) got %q, wanted nil", err)
+				}
+				if len(treeProof) != len(proof) {
+					t.Fatalf("process %d, %d to %d: (tree proof) got %d nodes, wanted %d", processType, m, n, len(treeProof), len(proof))
+				}
+				for i := range proof {
+					if !bytes.Equal(treeProof[i], proof[i]) {
+						t.Errorf("process %d, %d to %d: (tree proof) node %d differs", processType, m, n, i)
+					}
+				}
+			}
+		}
+	}
+
+	for _, processType := range []int{DupeAppend, BinaryTree} {
+		var unsupported *UnsupportedProcessTypeErr
+		if _, err := ConsistencyProof(makeIndexedLeaves(5), 2, 5, "SHA256SUM256", processType); !errors.As(err, &unsupported) {
+			t.Errorf("(err) got %v, wanted *UnsupportedProcessTypeErr", err)
+		}
+	}
+
+	// proof nodes are copies: writing to them does not change the tree
+	tree, _ := BuildTree(makeIndexedLeaves(8), "SHA256SUM256", PassThrough)
+	root := tree.Root()
+	level0, _ := tree.Level(0)
+	level1, _ := tree.Level(1)
+	proof, _ := tree.ConsistencyProof(3, 8)
+	for _, node := range proof {
+		clear(node)
+	}
+	if now0, _ := tree.Level(0); !slices.EqualFunc(now0, level0, bytes.Equal) {
+		t.Errorf("(leaves) changed through a consistency proof")
+	}
+	if now1, _ := tree.Level(1); !slices.EqualFunc(now1, level1, bytes.Equal) || !bytes.Equal(tree.Root(), root) {
+		t.Errorf("(nodes) changed through a consistency proof")
+	}
+
+	// under the caller's context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ms, _ := New(WithProcessType(PassThrough))
+	var timedOut *ProcessTimedOutErr
+	if _, err := ms.ConsistencyProofContext(ctx, makeIndexedLeaves(8), 3, 8); !errors.As(err, &timedOut) {
+		t.Errorf("(err) got %v, wanted *ProcessTimedOutErr", err)
+	}
+
+	tree, _ = BuildTree(makeIndexedLeaves(5), "SHA256SUM256", PassThrough)
+	var argErr *ArgumentErr
+	for _, sizes := range [][2]int{{0, 3}, {4, 3}, {3, 6}} {
+		if _, err := tree.ConsistencyProof(sizes[0], sizes[1]); !errors.As(err, &argErr) {
+			t.Errorf("sizes %v: (err) got %v, wanted *ArgumentErr", sizes, err)
+		}
+	}
+}
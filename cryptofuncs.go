@@ -6,20 +6,75 @@ package merkletree
 //	- AvailableAlgorithms (cryptofuncs.go):
 //		Returns the hash algoritms available in this module.
 //
+//	- RegisterAlgorithm, RegisterHasher, RegisterXOF, UnregisterAlgorithm, AlgorithmInfo:
+//		Runtime (un)registration of hash algorithms, safe for concurrent use.
+//		The registries are only read and written under registryLock.
+//
+// Services hash with the algorithm's Hasher (see hasher.go): the built-in fixed
+// size algorithms have one over their hash.Hash, other CryptoFuncs are adapted.
+//
 
 import (
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
+	"hash"
+	"strings"
+	"sync"
 
+	"github.com/yveshoebeke/merkletree/internal/blake3"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
+	"golang.org/x/crypto/ripemd160"
 	"golang.org/x/crypto/sha3"
 )
 
 type CryptoFunc func([]byte) []byte
 
+// Fixed size algorithms.
+// Use RegisterAlgorithm and UnregisterAlgorithm to change it: writing to the map
+// directly races with the services reading it. An entry written directly is
+// nevertheless treated as registered (its digest size is the function's).
 var AlgorithmRegistry map[string]CryptoFunc
 
+// Algorithm properties.
+type AlgorithmMetadata struct {
+	DigestSize int    `json:"digestsize"`    // in bytes, the default one for an XOF
+	OID        string `json:"oid,omitempty"` // ASN.1 object identifier, dotted notation
+}
+
+var (
+	registryLock      sync.RWMutex
+	algorithmMetadata map[string]AlgorithmMetadata
+	builtinAlgorithms map[string]bool
+	hashers           map[string]Hasher // fixed size algorithms
+)
+
+// Extendable-output function (XOF): the digest size (in bytes) is a parameter.
+// customization is cSHAKE's customization string (S), the function name (N) is empty.
+type XOFFunc func(data []byte, size int, customization []byte) []byte
+
+// Extendable-output functions, use RegisterXOF to add one.
+var xofRegistry map[string]XOFFunc
+
+// Digest size (in bytes) of a built-in XOF when none is requested (see WithDigestSize):
+// twice the security strength, as for the fixed size algorithms.
+var xofDefaultSizes = map[string]int{
+	"CSHAKE128": 32,
+	"CSHAKE256": 64,
+	"SHAKE128":  32,
+	"SHAKE256":  64,
+}
+
+// XOF states of the built-in XOFs (see newXOFHasher), of a customization.
+var xofFactories = map[string]func(customization []byte) sha3.ShakeHash{
+	"CSHAKE128": func(customization []byte) sha3.ShakeHash { return sha3.NewCShake128(nil, customization) },
+	"CSHAKE256": func(customization []byte) sha3.ShakeHash { return sha3.NewCShake256(nil, customization) },
+	"SHAKE128":  func([]byte) sha3.ShakeHash { return sha3.NewShake128() },
+	"SHAKE256":  func([]byte) sha3.ShakeHash { return sha3.NewShake256() },
+}
+
 func MD5(hash []byte) []byte {
 	sumResult := md5.Sum(hash)
 	return sumResult[:]
@@ -35,29 +90,346 @@ func SHA3SUM256(hash []byte) []byte {
 	return sumResult[:]
 }
 
+func SHA3SUM384(hash []byte) []byte {
+	sumResult := sha3.Sum384(hash)
+	return sumResult[:]
+}
+
+func SHA3SUM512(hash []byte) []byte {
+	sumResult := sha3.Sum512(hash)
+	return sumResult[:]
+}
+
+func SHA256SUM224(hash []byte) []byte {
+	sumResult := sha256.Sum224(hash)
+	return sumResult[:]
+}
+
 func SHA256SUM256(hash []byte) []byte {
 	sumResult := sha256.Sum256(hash)
 	return sumResult[:]
 }
 
+// Double SHA-256 (Bitcoin's hash256): sha256(sha256(data)).
+func SHA256D(hash []byte) []byte {
+	first := sha256.Sum256(hash)
+	sumResult := sha256.Sum256(first[:])
+	return sumResult[:]
+}
+
+func SHA512SUM384(hash []byte) []byte {
+	sumResult := sha512.Sum384(hash)
+	return sumResult[:]
+}
+
 func SHA512SUM256(hash []byte) []byte {
 	sumResult := sha512.Sum512_256(hash)
 	return sumResult[:]
 }
 
+// Legacy Keccak-256 (Ethereum's keccak256): not the standardized SHA3-256.
+func KECCAK256(hash []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(hash)
+	return h.Sum(nil)
+}
+
 func SHA512SUM512(hash []byte) []byte {
 	sumResult := sha512.Sum512(hash)
 	return sumResult[:]
 }
 
+func BLAKE2BSUM256(hash []byte) []byte {
+	sumResult := blake2b.Sum256(hash)
+	return sumResult[:]
+}
+
+func BLAKE2BSUM512(hash []byte) []byte {
+	sumResult := blake2b.Sum512(hash)
+	return sumResult[:]
+}
+
+func BLAKE2SSUM256(hash []byte) []byte {
+	sumResult := blake2s.Sum256(hash)
+	return sumResult[:]
+}
+
+// BLAKE3 (pure Go, internal/blake3), 32 byte digest.
+func BLAKE3(hash []byte) []byte {
+	sumResult := blake3.Sum256(hash)
+	return sumResult[:]
+}
+
+func RIPEMD160(hash []byte) []byte {
+	h := ripemd160.New()
+	h.Write(hash)
+	return h.Sum(nil)
+}
+
+// Bitcoin's hash160: ripemd160(sha256(data)).
+func HASH160(hash []byte) []byte {
+	first := sha256.Sum256(hash)
+	return RIPEMD160(first[:])
+}
+
+func SHAKE128(hash []byte, size int, _ []byte) []byte {
+	sumResult := make([]byte, size)
+	sha3.ShakeSum128(sumResult, hash)
+	return sumResult
+}
+
+func SHAKE256(hash []byte, size int, _ []byte) []byte {
+	sumResult := make([]byte, size)
+	sha3.ShakeSum256(sumResult, hash)
+	return sumResult
+}
+
+// cSHAKE128: SHAKE128 when customization is empty.
+func CSHAKE128(hash []byte, size int, customization []byte) []byte {
+	h := sha3.NewCShake128(nil, customization)
+	h.Write(hash)
+	sumResult := make([]byte, size)
+	h.Read(sumResult)
+	return sumResult
+}
+
+// cSHAKE256: SHAKE256 when customization is empty.
+func CSHAKE256(hash []byte, size int, customization []byte) []byte {
+	h := sha3.NewCShake256(nil, customization)
+	h.Write(hash)
+	sumResult := make([]byte, size)
+	h.Read(sumResult)
+	return sumResult
+}
+
 // Create function registry
 func init() {
 	AlgorithmRegistry = map[string]CryptoFunc{
-		"MD5":          MD5,
-		"SHA1":         SHA1,
-		"SHA3SUM256":   SHA3SUM256,
-		"SHA256SUM256": SHA256SUM256,
-		"SHA512SUM256": SHA512SUM256,
-		"SHA512SUM512": SHA512SUM512,
+		"BLAKE2BSUM256": BLAKE2BSUM256,
+		"BLAKE2BSUM512": BLAKE2BSUM512,
+		"BLAKE2SSUM256": BLAKE2SSUM256,
+		"BLAKE3":        BLAKE3,
+		"HASH160":       HASH160,
+		"KECCAK256":     KECCAK256,
+		"MD5":           MD5,
+		"RIPEMD160":     RIPEMD160,
+		"SHA1":          SHA1,
+		"SHA3SUM256":    SHA3SUM256,
+		"SHA3SUM384":    SHA3SUM384,
+		"SHA3SUM512":    SHA3SUM512,
+		"SHA256D":       SHA256D,
+		"SHA256SUM224":  SHA256SUM224,
+		"SHA256SUM256":  SHA256SUM256,
+		"SHA512SUM256":  SHA512SUM256,
+		"SHA512SUM384":  SHA512SUM384,
+		"SHA512SUM512":  SHA512SUM512,
+	}
+
+	// Double hashes (SHA256D, HASH160) are adapted from their CryptoFunc.
+	hashers = map[string]Hasher{
+		"BLAKE2BSUM256": NewHasher(func() hash.Hash { h, _ := blake2b.New256(nil); return h }),
+		"BLAKE2BSUM512": NewHasher(func() hash.Hash { h, _ := blake2b.New512(nil); return h }),
+		"BLAKE2SSUM256": NewHasher(func() hash.Hash { h, _ := blake2s.New256(nil); return h }),
+		"BLAKE3":        NewHasher(func() hash.Hash { return blake3.New() }),
+		"KECCAK256":     NewHasher(sha3.NewLegacyKeccak256),
+		"MD5":           NewHasher(md5.New),
+		"RIPEMD160":     NewHasher(ripemd160.New),
+		"SHA1":          NewHasher(sha1.New),
+		"SHA3SUM256":    NewHasher(sha3.New256),
+		"SHA3SUM384":    NewHasher(sha3.New384),
+		"SHA3SUM512":    NewHasher(sha3.New512),
+		"SHA256SUM224":  NewHasher(sha256.New224),
+		"SHA256SUM256":  NewHasher(sha256.New),
+		"SHA512SUM256":  NewHasher(sha512.New512_256),
+		"SHA512SUM384":  NewHasher(sha512.New384),
+		"SHA512SUM512":  NewHasher(sha512.New),
+	}
+	for name, fn := range AlgorithmRegistry {
+		if _, ok := hashers[name]; !ok {
+			hashers[name] = FuncHasher(fn)
+		}
+	}
+
+	xofRegistry = map[string]XOFFunc{
+		"CSHAKE128": CSHAKE128,
+		"CSHAKE256": CSHAKE256,
+		"SHAKE128":  SHAKE128,
+		"SHAKE256":  SHAKE256,
+	}
+
+	algorithmMetadata = map[string]AlgorithmMetadata{
+		"BLAKE2BSUM256": {32, "1.3.6.1.4.1.1722.12.2.1.8"},
+		"BLAKE2BSUM512": {64, "1.3.6.1.4.1.1722.12.2.1.16"},
+		"BLAKE2SSUM256": {32, "1.3.6.1.4.1.1722.12.2.2.8"},
+		"MD5":           {16, "1.2.840.113549.2.5"},
+		"RIPEMD160":     {20, "1.3.36.3.2.1"},
+		"SHA1":          {20, "1.3.14.3.2.26"},
+		"SHA256SUM224":  {28, "2.16.840.1.101.3.4.2.4"},
+		"SHA256SUM256":  {32, "2.16.840.1.101.3.4.2.1"},
+		"SHA3SUM256":    {32, "2.16.840.1.101.3.4.2.8"},
+		"SHA3SUM384":    {48, "2.16.840.1.101.3.4.2.9"},
+		"SHA3SUM512":    {64, "2.16.840.1.101.3.4.2.10"},
+		"SHA512SUM256":  {32, "2.16.840.1.101.3.4.2.6"},
+		"SHA512SUM384":  {48, "2.16.840.1.101.3.4.2.2"},
+		"SHA512SUM512":  {64, "2.16.840.1.101.3.4.2.3"},
+		"SHAKE128":      {32, "2.16.840.1.101.3.4.2.11"},
+		"SHAKE256":      {64, "2.16.840.1.101.3.4.2.12"},
+	}
+
+	builtinAlgorithms = map[string]bool{}
+	for name, fn := range AlgorithmRegistry {
+		builtinAlgorithms[name] = true
+		if _, ok := algorithmMetadata[name]; !ok {
+			algorithmMetadata[name] = AlgorithmMetadata{DigestSize: len(fn(nil))}
+		}
+	}
+	for name := range xofRegistry {
+		builtinAlgorithms[name] = true
+		if _, ok := algorithmMetadata[name]; !ok {
+			algorithmMetadata[name] = AlgorithmMetadata{DigestSize: xofDefaultSizes[name]}
+		}
+	}
+}
+
+/*
+Runtime registration
+  - Adds a fixed size algorithm under name (case insensitive), for all services
+    created afterwards. The digest size is fn's when metadata does not give it.
+*/
+func RegisterAlgorithm(name string, fn CryptoFunc, metadata AlgorithmMetadata) error {
+	if fn == nil {
+		return &ArgumentErr{"algorithm name and function required - "}
+	}
+
+	return registerHasher(name, FuncHasher(fn), fn, metadata)
+}
+
+/*
+Runtime registration (Hasher)
+  - Same as RegisterAlgorithm, nodes are hashed without concatenation.
+    ie: RegisterHasher("SHA224", NewHasher(sha256.New224), AlgorithmMetadata{})
+*/
+func RegisterHasher(name string, hasher Hasher, metadata AlgorithmMetadata) error {
+	if hasher == nil {
+		return &ArgumentErr{"algorithm name and hasher required - "}
+	}
+
+	return registerHasher(name, hasher, hasherFunc(hasher), metadata)
+}
+
+// Registers hasher and its CryptoFunc fn.
+func registerHasher(name string, hasher Hasher, fn CryptoFunc, metadata AlgorithmMetadata) error {
+	name = strings.ToUpper(name)
+	if name == "" {
+		return &ArgumentErr{"algorithm name required - "}
+	}
+
+	digestSize := hasher.Size()
+	if digestSize <= 0 {
+		return &ArgumentErr{"empty digest - "}
+	}
+	if len(fn(nil)) != digestSize || (metadata.DigestSize != 0 && metadata.DigestSize != digestSize) {
+		return &ArgumentErr{"digest size does not match the function's - "}
+	}
+	metadata.DigestSize = digestSize
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if isRegistered(name) {
+		return &DuplicateAlgorithmErr{name}
+	}
+	AlgorithmRegistry[name] = fn
+	hashers[name] = hasher
+	algorithmMetadata[name] = metadata
+
+	return nil
+}
+
+/*
+Runtime registration (XOF)
+  - Adds an extendable-output function under name (case insensitive), for all services
+    created afterwards. metadata.DigestSize is its digest size when WithDigestSize is not given.
+*/
+func RegisterXOF(name string, xof XOFFunc, metadata AlgorithmMetadata) error {
+	name = strings.ToUpper(name)
+	if name == "" || xof == nil {
+		return &ArgumentErr{"algorithm name and function required - "}
+	}
+	if metadata.DigestSize <= 0 {
+		return &ArgumentErr{"default digest size required - "}
+	}
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if isRegistered(name) {
+		return &DuplicateAlgorithmErr{name}
+	}
+	xofRegistry[name] = xof
+	algorithmMetadata[name] = metadata
+
+	return nil
+}
+
+// Removes an algorithm added with RegisterAlgorithm (or RegisterHasher, RegisterXOF).
+// Services already created with it keep it.
+func UnregisterAlgorithm(name string) error {
+	name = strings.ToUpper(name)
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if builtinAlgorithms[name] {
+		return &ArgumentErr{"built-in algorithm can not be unregistered - "}
+	}
+	if !isRegistered(name) {
+		return &ArgumentErr{"unknown algorithm - "}
+	}
+	delete(AlgorithmRegistry, name)
+	delete(hashers, name)
+	delete(xofRegistry, name)
+	delete(algorithmMetadata, name)
+
+	return nil
+}
+
+// Metadata of a registered algorithm (case insensitive).
+func AlgorithmInfo(name string) (AlgorithmMetadata, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	name = strings.ToUpper(name)
+	if metadata, ok := algorithmMetadata[name]; ok {
+		return metadata, true
+	}
+	if fn, ok := AlgorithmRegistry[name]; ok {
+		return AlgorithmMetadata{DigestSize: len(fn(nil))}, true
+	}
+
+	return AlgorithmMetadata{}, false
+}
+
+// Whether name (upper case) is taken, AlgorithmRegistry written directly included.
+// The caller holds registryLock.
+func isRegistered(name string) bool {
+	_, registered := algorithmMetadata[name]
+	_, direct := AlgorithmRegistry[name]
+
+	return registered || direct
+}
+
+// Registered hash functions of name (upper case): at most one of them is set.
+// A CryptoFunc written to AlgorithmRegistry directly is adapted.
+func lookupAlgorithm(name string) (Hasher, XOFFunc) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	if fn, ok := AlgorithmRegistry[name]; ok {
+		if hasher, ok := hashers[name]; ok {
+			return hasher, nil
+		}
+		return FuncHasher(fn), nil
 	}
+	return nil, xofRegistry[name]
 }
@@ -0,0 +1,278 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Digests of the empty message.
+var emptyDigests = map[string]string{
+	"BLAKE2BSUM256": "0e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8",
+	"BLAKE2BSUM512": "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce",
+	"BLAKE2SSUM256": "69217a3079908094e11121d042354a7c1f55b6482ca1a51e1b250dfd1ed0eef9",
+	"BLAKE3":        "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262",
+	"HASH160":       "b472a266d0bd89c13706a4132ccfb16f7c3b9fcb",
+	"KECCAK256":     "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470",
+	"MD5":           "d41d8cd98f00b204e9800998ecf8427e",
+	"RIPEMD160":     "9c1185a5c5e9fc54612808977ee8f548b2258d31",
+	"SHA1":          "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+	"SHA3SUM256":    "a7ffc6f8bf1ed76651c14756a061d662f580ff4de43b49fa82d80a4b80f8434a",
+	"SHA3SUM384":    "0c63a75b845e4f7d01107d852e4c2485c51a50aaaa94fc61995e71bbee983a2ac3713831264adb47fb6bd1e058d5f004",
+	"SHA3SUM512":    "a69f73cca23a9ac5c8b567dc185a756e97c982164fe25859e0d1dcc1475c80a615b2123af1f5f94c11e3e9402c3ac558f500199d95b6d3e301758586281dcd26",
+	"SHA256D":       "5df6e0e2761359d30a8275058e299fcc0381534545f55cf43e41983f5d4c9456",
+	"SHA256SUM224":  "d14a028c2a3a2bc9476102bb288234c415a2b01f828ea62ac5b3e42f",
+	"SHA256SUM256":  "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	"SHA512SUM256":  "c672b8d1ef56ed28ab87c3622c5114069bdd3ad7b8f9737498d0c01ecef0967a",
+	"SHA512SUM384":  "38b060a751ac96384cd9327eb1b1e36a21fdb71114be07434c0cc7bf63f6e1da274edebfe76f65fbd51ad2f14898b95b",
+	"SHA512SUM512":  "cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e",
+}
+
+func TestAlgorithmRegistry(t *testing.T) {
+	registryLock.RLock()
+	registered := maps.Clone(AlgorithmRegistry)
+	registryLock.RUnlock()
+
+	for name, fn := range registered {
+		expected, ok := emptyDigests[name]
+		if !ok {
+			t.Errorf("%s: no test vector", name)
+			continue
+		}
+		if output := hex.EncodeToString(fn([]byte{})); output != expected {
+			t.Errorf("%s: (out) got %q, wanted %q", name, output, expected)
+		}
+	}
+
+	available, err := AvailableAlgorithms()
+	if err != nil {
+		t.Fatalf("(err) got %q, wanted nil", err)
+	}
+	for name := range emptyDigests {
+		if !strings.Contains(available, `"`+name+`"`) {
+			t.Errorf("%s: not in %s", name, available)
+		}
+	}
+}
+
+func TestXOFAlgorithms(t *testing.T) {
+	// SHAKE digests of the empty message, at the default size
+	xofDigests := map[string]string{
+		"SHAKE128":  "7f9c2ba4e88f827d616045507605853ed73b8093f6efbc88eb1a6eacfa66ef26",
+		"SHAKE256":  "46b9dd2b0ba88d13233b3feb743eeb243fcd52ea62b81b82b50c27646ed5762fd75dc4ddd8c0f200cb05019d67b592f6fc821c49479ab48640292eacb3b7c4be",
+		"CSHAKE128": "7f9c2ba4e88f827d616045507605853ed73b8093f6efbc88eb1a6eacfa66ef26",
+		"CSHAKE256": "46b9dd2b0ba88d13233b3feb743eeb243fcd52ea62b81b82b50c27646ed5762fd75dc4ddd8c0f200cb05019d67b592f6fc821c49479ab48640292eacb3b7c4be",
+	}
+	for name, expected := range xofDigests {
+		ms, err := New(WithAlgorithm(name))
+		if err != nil {
+			t.Fatalf("%s: (err) got %q, wanted nil", name, err)
+		}
+		if output := hex.EncodeToString(ms.Hash([]byte{})); output != expected {
+			t.Errorf("%s: (out) got %q, wanted %q", name, output, expected)
+		}
+		if ms.digestSize != len(expected)/2 {
+			t.Errorf("%s: (digest size) got %d, wanted %d", name, ms.digestSize, len(expected)/2)
+		}
+	}
+
+	// configured size: a prefix of the longer output
+	for _, size := range []int{20, 64} {
+		ms, _ := New(WithAlgorithm("SHAKE256"), WithDigestSize(size))
+		if output := hex.EncodeToString(ms.Hash([]byte{})); output != xofDigests["SHAKE256"][:2*size] {
+			t.Errorf("size %d: (out) got %q", size, output)
+		}
+
+		leaves := makeIndexedLeaves(7)
+		proof, err := ms.GenerateProof(leaves, 3)
+		if err != nil {
+			t.Fatalf("size %d: (proof err) got %q, wanted nil", size, err)
+		}
+		if proof.DigestSize != size || len(proof.Root) != size {
+			t.Errorf("size %d: (proof) digest size %d, root %d bytes", size, proof.DigestSize, len(proof.Root))
+		}
+		if ok, err := ms.VerifyProof(leaves[3], proof, proof.Root); !ok || err != nil {
+			t.Errorf("size %d: (verify) got %v (%v), wanted true", size, ok, err)
+		}
+
+		// a proof for another digest size is rejected
+		other, _ := New(WithAlgorithm("SHAKE256"), WithDigestSize(size+1))
+		if _, err := other.VerifyProof(leaves[3], proof, proof.Root); err == nil {
+			t.Errorf("size %d: (verify err) got nil, wanted a digest size mismatch", size)
+		}
+	}
+
+	// customization: unrelated trees
+	plain, _ := New(WithAlgorithm("CSHAKE128"))
+	custom, _ := New(WithAlgorithm("CSHAKE128"), WithCustomization([]byte("my tree")))
+	plainRoot, _ := plain.Derive(makeIndexedLeaves(5))
+	customRoot, _ := custom.Derive(makeIndexedLeaves(5))
+	if hex.EncodeToString(plainRoot) == hex.EncodeToString(customRoot) {
+		t.Errorf("(customization) roots are equal")
+	}
+
+	for _, opts := range [][]Option{
+		{WithAlgorithm("SHA256SUM256"), WithDigestSize(20)},
+		{WithAlgorithm("SHAKE128"), WithDigestSize(-1)},
+		{WithAlgorithm("SHAKE128"), WithCustomization([]byte("x"))},
+	} {
+		if _, err := New(opts...); err == nil {
+			t.Errorf("(err) got nil, wanted an argument error")
+		}
+	}
+}
+
+func TestRegisterAlgorithm(t *testing.T) {
+	truncated := func(data []byte) []byte {
+		return SHA256SUM256(data)[:20]
+	}
+
+	if err := RegisterAlgorithm("sha256-160", truncated, AlgorithmMetadata{OID: "1.2.3.4"}); err != nil {
+		t.Fatalf("(err) got %q, wanted nil", err)
+	}
+	defer UnregisterAlgorithm("SHA256-160")
+
+	metadata, ok := AlgorithmInfo("SHA256-160")
+	if !ok || metadata.DigestSize != 20 || metadata.OID != "1.2.3.4" {
+		t.Errorf("(metadata) got %+v (%v), wanted {20 1.2.3.4}", metadata, ok)
+	}
+	if metadata, _ := AlgorithmInfo("sha256sum256"); metadata.DigestSize != 32 || metadata.OID != "2.16.840.1.101.3.4.2.1" {
+		t.Errorf("(built-in metadata) got %+v", metadata)
+	}
+
+	root, err := DeriveRoot(makeIndexedLeaves(5), "sha256-160", PassThrough)
+	if err != nil || len(root) != 20 {
+		t.Errorf("(out) got %x (%v), wanted a 20 byte root", root, err)
+	}
+	if available, _ := AvailableAlgorithms(); !strings.Contains(available, `"SHA256-160"`) {
+		t.Errorf("(available) got %s", available)
+	}
+
+	var duplicate *DuplicateAlgorithmErr
+	if err := RegisterAlgorithm("SHA256-160", truncated, AlgorithmMetadata{}); !errors.As(err, &duplicate) {
+		t.Errorf("(duplicate err) got %v, wanted DuplicateAlgorithmErr", err)
+	}
+	if err := RegisterAlgorithm("shake128", truncated, AlgorithmMetadata{}); !errors.As(err, &duplicate) {
+		t.Errorf("(duplicate xof err) got %v, wanted DuplicateAlgorithmErr", err)
+	}
+	if err := RegisterAlgorithm("OTHER", truncated, AlgorithmMetadata{DigestSize: 32}); err == nil {
+		t.Errorf("(digest size err) got nil, wanted an argument error")
+	}
+	if err := UnregisterAlgorithm("SHA256SUM256"); err == nil {
+		t.Errorf("(built-in err) got nil, wanted an argument error")
+	}
+
+	if err := UnregisterAlgorithm("SHA256-160"); err != nil {
+		t.Fatalf("(unregister err) got %q, wanted nil", err)
+	}
+	if _, err := DeriveRoot(makeIndexedLeaves(5), "SHA256-160", PassThrough); err == nil {
+		t.Errorf("(err) got nil, wanted unknown algorithm")
+	}
+}
+
+// An entry written to AlgorithmRegistry directly is a registered algorithm.
+func TestDirectRegistryWrite(t *testing.T) {
+	truncated := func(data []byte) []byte {
+		return SHA256SUM256(data)[:20]
+	}
+
+	registryLock.Lock()
+	AlgorithmRegistry["DIRECT-160"] = truncated
+	registryLock.Unlock()
+	defer UnregisterAlgorithm("DIRECT-160")
+
+	metadata, ok := AlgorithmInfo("direct-160")
+	if !ok || metadata.DigestSize != 20 {
+		t.Errorf("(metadata) got %+v (%v), wanted {20 }", metadata, ok)
+	}
+	if root, err := DeriveRoot(makeIndexedLeaves(5), "DIRECT-160", PassThrough); err != nil || len(root) != 20 {
+		t.Errorf("(out) got %x (%v), wanted a 20 byte root", root, err)
+	}
+
+	var duplicate *DuplicateAlgorithmErr
+	if err := RegisterAlgorithm("DIRECT-160", SHA256SUM256, AlgorithmMetadata{}); !errors.As(err, &duplicate) {
+		t.Errorf("(duplicate err) got %v, wanted DuplicateAlgorithmErr", err)
+	}
+	if err := RegisterXOF("DIRECT-160", SHAKE128, AlgorithmMetadata{DigestSize: 20}); !errors.As(err, &duplicate) {
+		t.Errorf("(duplicate xof err) got %v, wanted DuplicateAlgorithmErr", err)
+	}
+
+	if err := UnregisterAlgorithm("DIRECT-160"); err != nil {
+		t.Fatalf("(unregister err) got %q, wanted nil", err)
+	}
+	if _, ok := AlgorithmInfo("DIRECT-160"); ok {
+		t.Errorf("(metadata) got an unregistered algorithm")
+	}
+	if err := UnregisterAlgorithm("DIRECT-160"); err == nil {
+		t.Errorf("(unknown err) got nil, wanted an argument error")
+	}
+}
+
+func TestRegisterXOF(t *testing.T) {
+	// SHAKE256 under another name: same digests, its own default size
+	if err := RegisterXOF("myshake", SHAKE256, AlgorithmMetadata{DigestSize: 24}); err != nil {
+		t.Fatalf("(err) got %q, wanted nil", err)
+	}
+	defer UnregisterAlgorithm("MYSHAKE")
+
+	for _, size := range []int{0, 40} {
+		ms, err := New(WithAlgorithm("myshake"), WithDigestSize(size))
+		if err != nil {
+			t.Fatalf("size %d: (err) got %q, wanted nil", size, err)
+		}
+		expected := SHAKE256([]byte("data"), If(size == 0, 24, size), nil)
+		if digest := ms.Hash([]byte("data")); !bytes.Equal(digest, expected) {
+			t.Errorf("size %d: (digest) got %x, wanted %x", size, digest, expected)
+		}
+	}
+	if available, _ := AvailableAlgorithms(); !strings.Contains(available, `"MYSHAKE"`) {
+		t.Errorf("(available) got %s", available)
+	}
+
+	var duplicate *DuplicateAlgorithmErr
+	for _, name := range []string{"MYSHAKE", "shake128", "sha256sum256"} {
+		if err := RegisterXOF(name, SHAKE256, AlgorithmMetadata{DigestSize: 32}); !errors.As(err, &duplicate) {
+			t.Errorf("%s: (duplicate err) got %v, wanted DuplicateAlgorithmErr", name, err)
+		}
+	}
+	var argErr *ArgumentErr
+	for _, metadata := range []AlgorithmMetadata{{}, {DigestSize: -1}} {
+		if err := RegisterXOF("OTHER", SHAKE256, metadata); !errors.As(err, &argErr) {
+			t.Errorf("%+v: (err) got %v, wanted *ArgumentErr", metadata, err)
+		}
+	}
+	if err := RegisterXOF("OTHER", nil, AlgorithmMetadata{DigestSize: 32}); !errors.As(err, &argErr) {
+		t.Errorf("(nil err) got %v, wanted *ArgumentErr", err)
+	}
+
+	if err := UnregisterAlgorithm("myshake"); err != nil {
+		t.Fatalf("(unregister err) got %q, wanted nil", err)
+	}
+	if _, err := New(WithAlgorithm("MYSHAKE")); err == nil {
+		t.Errorf("(err) got nil, wanted unknown algorithm")
+	}
+}
+
+func TestRegisterAlgorithmConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		name := fmt.Sprintf("CONCURRENT%d", i)
+		go func() {
+			defer wg.Done()
+			RegisterAlgorithm(name, SHA1, AlgorithmMetadata{})
+			UnregisterAlgorithm(name)
+		}()
+		go func() {
+			defer wg.Done()
+			DeriveRoot(makeIndexedLeaves(9), "SHA256SUM256", PassThrough)
+			AvailableAlgorithms()
+		}()
+	}
+	wg.Wait()
+}
@@ -1,6 +1,7 @@
 package merkletree
 
 import (
+	"bytes"
 	"context"
 )
 
@@ -11,37 +12,82 @@ func (ms *MerkleService) processDuplicateAndAppendRequest(ctx context.Context) e
 		return &InvalidContextProcessTypeErr{contextProcessType.(string)}
 	}
 
-	started := false
+	return ms.duplicateAndAppend(ctx)
+}
+
+// Pair nodes level by level, the last node of an odd level is duplicated
+// (or paired otherwise, as the odd node policy says).
+func (ms *MerkleService) duplicateAndAppend(ctx context.Context) error {
+	// Note: a single leaf is hashed with itself by default,
+	//	so as to adhere to this Merkle tree discipline (see SingleLeafPolicy).
+	if len(ms.Leaves) == 1 {
+		return ms.singleLeafRoot()
+	}
 
-	for {
-		// One remaining: Exit the loop. Merkle tree root determined.
-		// Note: if initial data set is only 1 element, will continue
-		//	so as to adhere to this Merkle tree discipline.
-		if started && len(ms.Leaves) == 1 {
-			break
+	for level := 0; len(ms.Leaves) > 1; level++ {
+		if err := checkContext(ctx); err != nil {
+			return err
 		}
-		started = true
+
+		// - an even level ending in a duplicated pair has the root of the level
+		//	without it (CVE-2012-2459): [1] [2] [3] [3] => [1] [2] [3] [3]
+		if count := len(ms.Leaves); ms.oddNodes == DuplicateOddNodes && count%2 == 0 && bytes.Equal(ms.Leaves[count-2], ms.Leaves[count-1]) {
+			ms.Mutated = true
+			if ms.strictMutation {
+				return &MutatedTreeErr{level, count - 2}
+			}
+		}
+
 		//  - adjust for odd number of leaves by duplicating last leave and appending it.
 		//	- ie:
 		//		[1] [2] [3] [4] [5] => [1] [2] [3] [4] [5] [5]
-		if len(ms.Leaves)%2 == 1 {
-			ms.Leaves = append(ms.Leaves, ms.Leaves[len(ms.Leaves)-1])
-		}
+		ms.padOddLevel()
+
+		ms.proveLevel(0)
+
 		// - combine (concatenate) hash of left and right (in couple)
 		// - encode it with requested algorithm
-		// - Zero (nil) out the right element's value
 		//	- ie:
-		// 		[1] [2] [3] [4] => [12] [0] [34] [0]
-		for index := 0; index < len(ms.Leaves); index += 2 {
-			ms.Leaves[index] = ms.hashGenerator(append(ms.Leaves[index][:], ms.Leaves[index+1][:]...))
-			ms.Leaves[index+1] = []byte{}
-		}
-
-		// Remove 'nill' bytes.
-		ms.removeNillBytes(DupeAppend, 0)
+		// 		[1] [2] [3] [4] => [12] [34]
+		ms.hashLevel(0)
+		ms.recordLevel()
 	}
 
 	ms.ProcessResult = ms.Leaves[0]
 
 	return nil
 }
+
+/*
+Mutation detection (CVE-2012-2459)
+  - The last node of an odd level is duplicated, so [1] [2] [3] and [1] [2] [3] [3]
+    have the same root. Detects leaves whose tree (at any level) ends in such a
+    duplicated pair. Only the DupeAppend and Bitcoin process types are affected.
+*/
+func DetectMutation(hashes [][]byte, algorithmRequested string, processType int) (bool, error) {
+	ms, err := New(WithAlgorithm(algorithmRequested), WithProcessType(processType))
+	if err != nil {
+		return false, err
+	}
+
+	return ms.DetectMutation(hashes)
+}
+
+// Detect a duplicated trailing pair in the tree of hashes with the service's configuration.
+func (ms *MerkleService) DetectMutation(hashes [][]byte) (bool, error) {
+	if ms.ProcessType != DupeAppend && ms.ProcessType != Bitcoin {
+		return false, &UnsupportedProcessTypeErr{"mutation detection", ms.ProcessType}
+	}
+
+	job, err := ms.newJob(hashes)
+	if err != nil {
+		return false, err
+	}
+	job.strictMutation = false
+
+	if err := ms.execute(context.Background(), job); err != nil {
+		return false, err
+	}
+
+	return job.Mutated, nil
+}
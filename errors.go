@@ -15,6 +15,110 @@ func (argerr *ArgumentErr) Error() string {
 	return fmt.Sprintf("argument error(s) - %s", argerr.invalidArguments)
 }
 
+// - algorithm name already registered
+type DuplicateAlgorithmErr struct {
+	name string
+}
+
+func (duplicate *DuplicateAlgorithmErr) Error() string {
+	return fmt.Sprintf("algorithm already registered: %s", duplicate.name)
+}
+
+// - process type name already registered
+type DuplicateProcessTypeErr struct {
+	name string
+}
+
+func (duplicate *DuplicateProcessTypeErr) Error() string {
+	return fmt.Sprintf("process type already registered: %s", duplicate.name)
+}
+
+// - custom process type's strategy planned an impossible level
+type InvalidStrategyErr struct {
+	name   string
+	reason string
+}
+
+func (strategyerr *InvalidStrategyErr) Error() string {
+	return fmt.Sprintf("invalid strategy of %s: %s", strategyerr.name, strategyerr.reason)
+}
+
+// - leaf index out of range of the data
+type LeafIndexErr struct {
+	leafIndex int
+	leafCount int
+}
+
+func (idxerr *LeafIndexErr) Error() string {
+	return fmt.Sprintf("leaf index %d out of range [0, %d)", idxerr.leafIndex, idxerr.leafCount)
+}
+
+// - empty leaf, rejected by the empty leaf policy
+type EmptyLeafErr struct {
+	leafIndex int
+}
+
+func (emptyerr *EmptyLeafErr) Error() string {
+	return fmt.Sprintf("empty leaf at index %d", emptyerr.leafIndex)
+}
+
+// - tree level or node index out of range
+type NodeIndexErr struct {
+	level int
+	index int
+}
+
+func (nodeerr *NodeIndexErr) Error() string {
+	return fmt.Sprintf("no node at level %d, index %d", nodeerr.level, nodeerr.index)
+}
+
+// - malformed inclusion proof
+type InvalidProofErr struct {
+	reason string
+}
+
+func (prferr *InvalidProofErr) Error() string {
+	return fmt.Sprintf("invalid proof: %s", prferr.reason)
+}
+
+// - operation not available for the process type
+type UnsupportedProcessTypeErr struct {
+	operation   string
+	processType int
+}
+
+func (unsupported *UnsupportedProcessTypeErr) Error() string {
+	return fmt.Sprintf("%s not supported for process type %d", unsupported.operation, unsupported.processType)
+}
+
+// - value can not be ABI encoded
+type ABIEncodingErr struct {
+	reason string
+}
+
+func (abierr *ABIEncodingErr) Error() string {
+	return fmt.Sprintf("abi encoding: %s", abierr.reason)
+}
+
+// - OpenZeppelin tree dump does not hold together
+type InvalidStandardTreeErr struct {
+	reason string
+}
+
+func (treeerr *InvalidStandardTreeErr) Error() string {
+	return fmt.Sprintf("invalid standard merkle tree: %s", treeerr.reason)
+}
+
+// - duplicated trailing pair collides with the odd level duplication (CVE-2012-2459)
+type MutatedTreeErr struct {
+	level int
+	index int
+}
+
+func (mutated *MutatedTreeErr) Error() string {
+	return fmt.Sprintf("mutated tree: level %d nodes %d and %d are duplicates", mutated.level, mutated.index, mutated.index+1)
+}
+
 // - process type value does not match context
 type InvalidContextProcessTypeErr struct {
 	contextProcess string
@@ -32,3 +136,7 @@ type ProcessTimedOutErr struct {
 func (ctxTimeout *ProcessTimedOutErr) Error() string {
 	return fmt.Sprintf("timed out: %+v", ctxTimeout.ctxError)
 }
+
+func (ctxTimeout *ProcessTimedOutErr) Unwrap() error {
+	return ctxTimeout.ctxError
+}
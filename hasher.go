@@ -0,0 +1,156 @@
+package merkletree
+
+//
+// Hashers: digests written to the caller's buffer, from reused hash state.
+//
+// Functions:
+//
+//	- NewHasher:
+//		Hasher of a hash.Hash factory. States are pooled, Reset and reused.
+//
+//	- CryptoHasher:
+//		Hasher of a crypto.Hash (ie: crypto.SHA256).
+//
+//	- FuncHasher:
+//		Adapter of a CryptoFunc: its parts are concatenated in a pooled buffer.
+//
+//	- RegisterHasher (cryptofuncs.go):
+//		Registers a Hasher as an algorithm.
+//
+// Nodes are hashed by writing prefix, left and right to the hash state, and the
+// digest is appended to its slot of the digest buffer (see levelBuffer.go):
+// no concatenated copy, no digest allocation.
+//
+
+import (
+	"crypto"
+	"hash"
+	"slices"
+	"sync"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Hash function of an algorithm. Safe for concurrent use.
+type Hasher interface {
+	// Appends the digest of a || b || c (any of them may be empty) to dst.
+	// The parts are read before dst is written: dst may overlap them.
+	Sum(dst, a, b, c []byte) []byte
+	// Digest size in bytes.
+	Size() int
+}
+
+// Pooled hash.Hash states.
+type poolHasher struct {
+	states sync.Pool
+	size   int
+}
+
+// Hasher of a hash.Hash factory (ie: sha256.New).
+func NewHasher(factory func() hash.Hash) Hasher {
+	h := &poolHasher{size: factory().Size()}
+	h.states.New = func() any {
+		return factory()
+	}
+	return h
+}
+
+// Hasher of a crypto.Hash, its implementation must be linked in (ie: import _ "crypto/sha256").
+func CryptoHasher(h crypto.Hash) (Hasher, error) {
+	if !h.Available() {
+		return nil, &ArgumentErr{"crypto.Hash not available - "}
+	}
+	return NewHasher(h.New), nil
+}
+
+func (h *poolHasher) Sum(dst, a, b, c []byte) []byte {
+	state := h.states.Get().(hash.Hash)
+	state.Reset()
+	state.Write(a)
+	state.Write(b)
+	state.Write(c)
+	dst = state.Sum(dst)
+	h.states.Put(state)
+
+	return dst
+}
+
+func (h *poolHasher) Size() int {
+	return h.size
+}
+
+// CryptoFunc adapter.
+type funcHasher struct {
+	fn      CryptoFunc
+	buffers sync.Pool
+	size    int
+}
+
+// Hasher of a CryptoFunc: the parts are concatenated, then hashed.
+func FuncHasher(fn CryptoFunc) Hasher {
+	h := &funcHasher{fn: fn, size: len(fn(nil))}
+	h.buffers.New = func() any {
+		return new([]byte)
+	}
+	return h
+}
+
+func (h *funcHasher) Sum(dst, a, b, c []byte) []byte {
+	buffer := h.buffers.Get().(*[]byte)
+	*buffer = append(append(append((*buffer)[:0], a...), b...), c...)
+	dst = append(dst, h.fn(*buffer)...)
+	h.buffers.Put(buffer)
+
+	return dst
+}
+
+func (h *funcHasher) Size() int {
+	return h.size
+}
+
+// Pooled XOF states, read to a fixed digest size.
+type xofHasher struct {
+	states sync.Pool
+	size   int
+}
+
+// Hasher of an XOF with a digest size (and customization, cSHAKE only).
+// An XOF without a state factory is adapted from its XOFFunc.
+func newXOFHasher(name string, xof XOFFunc, size int, customization []byte) Hasher {
+	factory, ok := xofFactories[name]
+	if !ok {
+		return FuncHasher(func(data []byte) []byte {
+			return xof(data, size, customization)
+		})
+	}
+
+	h := &xofHasher{size: size}
+	h.states.New = func() any {
+		return factory(customization)
+	}
+	return h
+}
+
+func (h *xofHasher) Sum(dst, a, b, c []byte) []byte {
+	state := h.states.Get().(sha3.ShakeHash)
+	state.Reset()
+	state.Write(a)
+	state.Write(b)
+	state.Write(c)
+	dst = slices.Grow(dst, h.size)
+	state.Read(dst[len(dst) : len(dst)+h.size])
+	h.states.Put(state)
+
+	return dst[:len(dst)+h.size]
+}
+
+func (h *xofHasher) Size() int {
+	return h.size
+}
+
+// CryptoFunc of a Hasher.
+func hasherFunc(h Hasher) CryptoFunc {
+	return func(data []byte) []byte {
+		return h.Sum(nil, data, nil, nil)
+	}
+}
@@ -0,0 +1,112 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha512"
+	"maps"
+	"testing"
+)
+
+func TestHashers(t *testing.T) {
+	registryLock.RLock()
+	registered := maps.Clone(AlgorithmRegistry)
+	registryLock.RUnlock()
+
+	a, b, c := []byte("prefix"), makeIndexedLeaves(2)[0], bytes.Repeat([]byte{0x5a}, 200)
+	whole := append(append(append([]byte{}, a...), b...), c...)
+
+	for name, fn := range registered {
+		hasher, _ := lookupAlgorithm(name)
+		want := fn(whole)
+		if got := hasher.Sum(nil, a, b, c); !bytes.Equal(got, want) {
+			t.Errorf("(%s) got %x, wanted %x", name, got, want)
+		}
+		if hasher.Size() != len(want) {
+			t.Errorf("(%s size) got %d, wanted %d", name, hasher.Size(), len(want))
+		}
+
+		// twice: pooled states are reset
+		dst := []byte{0xff}
+		if got := hasher.Sum(dst, a, b, c); !bytes.Equal(got[1:], want) || got[0] != 0xff {
+			t.Errorf("(%s append) got %x, wanted ff%x", name, got, want)
+		}
+
+		// in place: dst overlaps the parts
+		buffer := append([]byte{}, whole...)
+		if got := hasher.Sum(buffer[:0], buffer, nil, nil); !bytes.Equal(got, want) {
+			t.Errorf("(%s in place) got %x, wanted %x", name, got, want)
+		}
+	}
+}
+
+func TestXOFHashers(t *testing.T) {
+	tests := []struct {
+		name          string
+		size          int
+		customization []byte
+	}{
+		{"SHAKE128", 32, nil},
+		{"SHAKE256", 100, nil},
+		{"CSHAKE128", 16, []byte("tree")},
+		{"CSHAKE256", 64, []byte("tree")},
+	}
+
+	for _, tt := range tests {
+		_, xof := lookupAlgorithm(tt.name)
+		hasher := newXOFHasher(tt.name, xof, tt.size, tt.customization)
+		want := xof([]byte("leftright"), tt.size, tt.customization)
+
+		for i := 0; i < 2; i++ {
+			if got := hasher.Sum(nil, []byte("left"), []byte("right"), nil); !bytes.Equal(got, want) {
+				t.Errorf("(%s) got %x, wanted %x", tt.name, got, want)
+			}
+		}
+		if hasher.Size() != tt.size {
+			t.Errorf("(%s size) got %d, wanted %d", tt.name, hasher.Size(), tt.size)
+		}
+	}
+}
+
+func TestRegisterHasher(t *testing.T) {
+	hasher, err := CryptoHasher(crypto.SHA512_224)
+	if err != nil {
+		t.Fatalf("(err) got %q, wanted nil", err)
+	}
+	if err := RegisterHasher("sha512sum224", hasher, AlgorithmMetadata{OID: "2.16.840.1.101.3.4.2.5"}); err != nil {
+		t.Fatalf("(err) got %q, wanted nil", err)
+	}
+	defer UnregisterAlgorithm("SHA512SUM224")
+
+	if metadata, _ := AlgorithmInfo("SHA512SUM224"); metadata.DigestSize != 28 {
+		t.Errorf("(metadata) got %+v, wanted a 28 byte digest", metadata)
+	}
+
+	leaves := makeIndexedLeaves(2)
+	root, err := DeriveRoot(leaves, "SHA512SUM224", PassThrough)
+	want := sha512.Sum512_224(append(append([]byte{}, leaves[0]...), leaves[1]...))
+	if err != nil || !bytes.Equal(root, want[:]) {
+		t.Errorf("(out) got %x (%v), wanted %x", root, err, want)
+	}
+
+	if err := RegisterHasher("NONE", nil, AlgorithmMetadata{}); err == nil {
+		t.Errorf("(nil err) got nil, wanted an argument error")
+	}
+	if _, err := CryptoHasher(crypto.MD4); err == nil {
+		t.Errorf("(unavailable err) got nil, wanted an argument error")
+	}
+}
+
+func TestHasherAllocations(t *testing.T) {
+	ms, err := New(WithAlgorithm("SHA256SUM256"), WithProcessType(BinaryTree))
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := makeIndexedLeaves(1000)
+
+	// the job's copy of the leaves and its digest buffer, not one digest per node
+	// (a few more with -race: it drops pooled states at random)
+	if allocs := testing.AllocsPerRun(10, func() { ms.Derive(leaves) }); allocs >= float64(len(leaves)/2) {
+		t.Errorf("(allocs) got %v, wanted far less than one per node (%d leaves)", allocs, len(leaves))
+	}
+}
@@ -0,0 +1,281 @@
+package merkletree
+
+//
+// Inclusion proofs (audit paths).
+//
+// Functions:
+//
+//	- GenerateProof:
+//		Returns the sibling hashes (and their position) needed to recompute
+//		the root from a given leaf.
+//
+//	- VerifyProof:
+//		Recomputes the root from a leaf and its proof and compares it (in constant time)
+//		with the expected root.
+//
+// Notes:
+//
+//	- PassThrough promotes an unpaired node to the next level without hashing,
+//	  so there is no sibling at that level: path lengths vary per leaf.
+//	  A padded odd node (see OddNodePolicy) has its pad as sibling.
+//	- BinaryTree leaves left of the starting index are not paired at the first level.
+//
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Position of the sibling relative to the node on the path.
+type SiblingPosition int
+
+const (
+	SiblingLeft  SiblingPosition = iota // hash(sibling || node)
+	SiblingRight                        // hash(node || sibling)
+)
+
+// One level of an audit path.
+type ProofStep struct {
+	Hash     []byte          `json:"hash"`
+	Position SiblingPosition `json:"position"`
+}
+
+// Inclusion proof of the leaf at LeafIndex.
+type Proof struct {
+	HashTypeID  string      `json:"hashtype"`
+	DigestSize  int         `json:"digestsize,omitempty"`
+	ProcessType int         `json:"processtype"`
+	LeafIndex   int         `json:"leafindex"`
+	LeafCount   int         `json:"leafcount"`
+	Root        []byte      `json:"root"`
+	Path        []ProofStep `json:"path"`
+}
+
+/*
+Entry Point (proof)
+  - Inclusion proof of the leaf at leafIndex in the tree built from hashes.
+*/
+func GenerateProof(hashes [][]byte, leafIndex int, algorithmRequested string, processType int) (*Proof, error) {
+	ms, err := New(WithAlgorithm(algorithmRequested), WithProcessType(processType))
+	if err != nil {
+		return nil, err
+	}
+
+	return ms.GenerateProof(hashes, leafIndex)
+}
+
+// Inclusion proof of the leaf at leafIndex with the service's configuration.
+func (ms *MerkleService) GenerateProof(hashes [][]byte, leafIndex int) (*Proof, error) {
+	return ms.GenerateProofContext(context.Background(), hashes, leafIndex)
+}
+
+// Inclusion proof of the leaf at leafIndex under ctx.
+func (ms *MerkleService) GenerateProofContext(ctx context.Context, hashes [][]byte, leafIndex int) (*Proof, error) {
+	if ms.ProcessType == BLAKE3Tree {
+		return nil, &UnsupportedProcessTypeErr{"inclusion proof", ms.ProcessType}
+	}
+	if leafIndex < 0 || leafIndex >= len(hashes) {
+		return nil, &LeafIndexErr{leafIndex, len(hashes)}
+	}
+
+	job, err := ms.newJob(hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	job.ProofRequest = true
+	job.proofIndex = leafIndex
+	job.ProofResult = &Proof{
+		HashTypeID:  ms.HashTypeID,
+		DigestSize:  ms.digestSize,
+		ProcessType: ms.ProcessType,
+		LeafIndex:   leafIndex,
+		LeafCount:   len(hashes),
+		Path:        []ProofStep{},
+	}
+
+	if err := ms.execute(ctx, job); err != nil {
+		return nil, err
+	}
+
+	job.ProofResult.Root = job.ProcessResult
+
+	return job.ProofResult, nil
+}
+
+/*
+Entry Point (verification)
+  - Checks that leaf, with proof, hashes up to root.
+    Only root and proof are needed: not the full data set.
+*/
+func VerifyProof(leaf []byte, proof *Proof, root []byte, algorithmRequested string, processType int) (bool, error) {
+	ms, err := New(WithAlgorithm(algorithmRequested), WithProcessType(processType))
+	if err != nil {
+		return false, err
+	}
+
+	return ms.VerifyProof(leaf, proof, root)
+}
+
+// Checks that leaf, with proof, hashes up to root with the service's configuration.
+// A malformed proof returns an error; a well-formed proof that does not match root returns false.
+func (ms *MerkleService) VerifyProof(leaf []byte, proof *Proof, root []byte) (bool, error) {
+	if ms.ProcessType == BLAKE3Tree {
+		return false, &UnsupportedProcessTypeErr{"inclusion proof", ms.ProcessType}
+	}
+	if err := ms.validateProof(proof); err != nil {
+		return false, err
+	}
+	if len(leaf) == 0 && ms.emptyLeaves == RejectEmptyLeaves {
+		return false, &EmptyLeafErr{proof.LeafIndex}
+	}
+
+	// Per request copy of the service (working buffer).
+	job := *ms
+
+	node := job.prepareLeaf(leaf)
+	if proof.LeafCount == 1 && ms.singleLeaf == HashSingleLeaf {
+		node = job.hashPair(node, nil)
+	}
+
+	for _, step := range proof.Path {
+		if step.Position == SiblingLeft {
+			node = job.hashPair(step.Hash, node)
+		} else {
+			node = job.hashPair(node, step.Hash)
+		}
+	}
+
+	return subtle.ConstantTimeCompare(job.finalRoot(node), root) == 1, nil
+}
+
+// Proof must match the service's configuration and have the shape of the process type's tree.
+func (ms *MerkleService) validateProof(proof *Proof) error {
+	if proof == nil {
+		return &InvalidProofErr{"missing proof"}
+	}
+	if proof.HashTypeID != "" && strings.ToUpper(proof.HashTypeID) != ms.HashTypeID {
+		return &InvalidProofErr{fmt.Sprintf("algorithm %s, expected %s", proof.HashTypeID, ms.HashTypeID)}
+	}
+	if proof.DigestSize != 0 && proof.DigestSize != ms.digestSize {
+		return &InvalidProofErr{fmt.Sprintf("digest size %d, expected %d", proof.DigestSize, ms.digestSize)}
+	}
+	if proof.ProcessType != ms.ProcessType {
+		return &InvalidProofErr{fmt.Sprintf("process type %d, expected %d", proof.ProcessType, ms.ProcessType)}
+	}
+	if proof.LeafIndex < 0 || proof.LeafIndex >= proof.LeafCount {
+		return &LeafIndexErr{proof.LeafIndex, proof.LeafCount}
+	}
+
+	// StandardMerkleTree: the shape depends on the leaf's sorted position, unknown here.
+	// Pairs are sorted, positions do not matter.
+	if ms.ProcessType == StandardMerkleTree {
+		for level, step := range proof.Path {
+			if len(step.Hash) == 0 {
+				return &InvalidProofErr{fmt.Sprintf("empty sibling hash at level %d", level)}
+			}
+		}
+		return nil
+	}
+
+	expected := ms.proofPositions(proof.LeafIndex, proof.LeafCount)
+	if ms.strategy != nil {
+		expected = strategyPositions(ms.processName, ms.strategy, proof.LeafIndex, proof.LeafCount)
+	}
+	if len(proof.Path) != len(expected) {
+		return &InvalidProofErr{fmt.Sprintf("path length %d, expected %d", len(proof.Path), len(expected))}
+	}
+	for level, step := range proof.Path {
+		if step.Position != expected[level] {
+			return &InvalidProofErr{fmt.Sprintf("sibling position at level %d", level)}
+		}
+		if len(step.Hash) == 0 {
+			return &InvalidProofErr{fmt.Sprintf("empty sibling hash at level %d", level)}
+		}
+	}
+
+	return nil
+}
+
+// Sibling positions, per level, of the audit path of leafIndex in a tree of leafCount leaves.
+func (ms *MerkleService) proofPositions(leafIndex, leafCount int) []SiblingPosition {
+	positions := []SiblingPosition{}
+	index, count := leafIndex, leafCount
+
+	record := func(offset int) {
+		sibling, next := pairSibling(index, offset, count)
+		if sibling >= 0 {
+			positions = append(positions, If(sibling < index, SiblingLeft, SiblingRight))
+		}
+		index = next
+	}
+
+	// odd levels are padded, unless their last node is promoted
+	padded := ms.oddNodes != PromoteOddNodes
+
+	// single leaf: paired as an odd node, or no path at all (see SingleLeafPolicy)
+	if count == 1 {
+		if ms.singleLeaf == PairSingleLeaf && padded {
+			count++
+			record(0)
+		}
+		return positions
+	}
+
+	switch ms.ProcessType {
+	case PassThrough, RFC6962, DupeAppend, Bitcoin:
+		for count > 1 {
+			if padded {
+				count += count % 2
+			}
+			record(0)
+			count = (count + 1) / 2
+		}
+
+	case BinaryTree, Monero:
+		startIndex := binaryTreeStartIndex(count)
+		record(startIndex)
+		count = startIndex + (count-startIndex)/2
+		for count > 1 {
+			record(0)
+			count /= 2
+		}
+	}
+
+	return positions
+}
+
+// Record the sibling of the proven node at the current level (if it has one),
+// then move the proven node to its index in the next level.
+// Nodes are paired from offset onwards: [offset] [offset+1], [offset+2] [offset+3], ...
+func (ms *MerkleService) proveLevel(offset int) {
+	if !ms.ProofRequest || ms.proofIndex < offset {
+		return
+	}
+
+	sibling, next := pairSibling(ms.proofIndex, offset, len(ms.Leaves))
+	if sibling >= 0 {
+		ms.ProofResult.Path = append(ms.ProofResult.Path, ProofStep{
+			Hash:     slices.Clone(ms.Leaves[sibling]),
+			Position: If(sibling < ms.proofIndex, SiblingLeft, SiblingRight),
+		})
+	}
+
+	ms.proofIndex = next
+}
+
+// Sibling of the node at index when the count nodes of a level are paired from offset onwards
+// (-1 if it is not paired at this level), and the node's index in the next level.
+func pairSibling(index, offset, count int) (sibling, next int) {
+	if index < offset {
+		return -1, index
+	}
+
+	relative := index - offset
+	sibling = offset + (relative ^ 1)
+
+	return If(sibling < count, sibling, -1), offset + relative/2
+}
@@ -0,0 +1,125 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// leaves of count elements: SHA256SUM256 of their index.
+func makeIndexedLeaves(count int) [][]byte {
+	leaves := make([][]byte, count)
+	for i := range leaves {
+		leaves[i] = SHA256SUM256([]byte(fmt.Sprint(i)))
+	}
+	return leaves
+}
+
+// recompute the root from a leaf and its audit path
+func foldProof(leaf []byte, proof *Proof) []byte {
+	node := leaf
+	for _, step := range proof.Path {
+		if step.Position == SiblingLeft {
+			node = SHA256SUM256(append(append([]byte{}, step.Hash...), node...))
+		} else {
+			node = SHA256SUM256(append(append([]byte{}, node...), step.Hash...))
+		}
+	}
+	return node
+}
+
+func TestGenerateProof(t *testing.T) {
+	for _, processType := range []int{PassThrough, DupeAppend, BinaryTree} {
+		for count := 1; count <= 17; count++ {
+			if processType == BinaryTree && count == 1 {
+				continue
+			}
+
+			root, err := DeriveRoot(makeIndexedLeaves(count), "SHA256SUM256", processType)
+			if err != nil {
+				t.Fatalf("(err) got %q, wanted nil", err)
+			}
+
+			for leafIndex := 0; leafIndex < count; leafIndex++ {
+				proof, err := GenerateProof(makeIndexedLeaves(count), leafIndex, "SHA256SUM256", processType)
+				if err != nil {
+					t.Fatalf("(err) got %q, wanted nil", err)
+				}
+				if !bytes.Equal(proof.Root, root) {
+					t.Errorf("process %d, %d leaves: (root) got %q, wanted %q", processType, count, hex.EncodeToString(proof.Root), hex.EncodeToString(root))
+				}
+				if got := foldProof(makeIndexedLeaves(count)[leafIndex], proof); !bytes.Equal(got, root) {
+					t.Errorf("process %d, %d leaves, leaf %d: (fold) got %q, wanted %q", processType, count, leafIndex, hex.EncodeToString(got), hex.EncodeToString(root))
+				}
+			}
+		}
+	}
+
+	// PassThrough: the promoted last leaf of 5 has a shorter path
+	proof, _ := GenerateProof(makeIndexedLeaves(5), 4, "SHA256SUM256", PassThrough)
+	if len(proof.Path) != 1 || proof.Path[0].Position != SiblingLeft {
+		t.Errorf("(path) got %d steps, wanted 1 left sibling", len(proof.Path))
+	}
+
+	var idxErr *LeafIndexErr
+	if _, err := GenerateProof(makeIndexedLeaves(5), 5, "SHA256SUM256", PassThrough); !errors.As(err, &idxErr) {
+		t.Errorf("(err) got %v, wanted *LeafIndexErr", err)
+	}
+}
+
+func TestVerifyProof(t *testing.T) {
+	for _, processType := range []int{PassThrough, DupeAppend, BinaryTree} {
+		for count := 1; count <= 17; count++ {
+			if processType == BinaryTree && count == 1 {
+				continue
+			}
+
+			for leafIndex := 0; leafIndex < count; leafIndex++ {
+				leaf := makeIndexedLeaves(count)[leafIndex]
+				proof, err := GenerateProof(makeIndexedLeaves(count), leafIndex, "SHA256SUM256", processType)
+				if err != nil {
+					t.Fatalf("(err) got %q, wanted nil", err)
+				}
+
+				ok, err := VerifyProof(leaf, proof, proof.Root, "SHA256SUM256", processType)
+				if !ok || err != nil {
+					t.Errorf("process %d, %d leaves, leaf %d: got %v (%v), wanted true", processType, count, leafIndex, ok, err)
+				}
+
+				// wrong leaf
+				if ok, _ := VerifyProof(SHA256SUM256([]byte("nope")), proof, proof.Root, "SHA256SUM256", processType); ok {
+					t.Errorf("process %d, %d leaves, leaf %d: wrong leaf verified", processType, count, leafIndex)
+				}
+			}
+		}
+	}
+
+	proof, _ := GenerateProof(makeIndexedLeaves(6), 2, "SHA256SUM256", DupeAppend)
+	leaf := makeIndexedLeaves(6)[2]
+
+	// tampered sibling
+	tampered := *proof
+	tampered.Path = append([]ProofStep{{Hash: SHA256SUM256([]byte("nope")), Position: proof.Path[0].Position}}, proof.Path[1:]...)
+	if ok, err := VerifyProof(leaf, &tampered, proof.Root, "SHA256SUM256", DupeAppend); ok || err != nil {
+		t.Errorf("tampered sibling: got %v (%v), wanted false", ok, err)
+	}
+
+	// malformed proofs
+	truncated := *proof
+	truncated.Path = proof.Path[1:]
+	swapped := *proof
+	swapped.Path = append([]ProofStep{{Hash: proof.Path[0].Hash, Position: 1 - proof.Path[0].Position}}, proof.Path[1:]...)
+	for _, malformed := range []*Proof{nil, &truncated, &swapped} {
+		var prfErr *InvalidProofErr
+		if _, err := VerifyProof(leaf, malformed, proof.Root, "SHA256SUM256", DupeAppend); !errors.As(err, &prfErr) {
+			t.Errorf("(err) got %v, wanted *InvalidProofErr", err)
+		}
+	}
+
+	var prfErr *InvalidProofErr
+	if _, err := VerifyProof(leaf, proof, proof.Root, "SHA256SUM256", PassThrough); !errors.As(err, &prfErr) {
+		t.Errorf("(err) got %v, wanted *InvalidProofErr", err)
+	}
+}
@@ -0,0 +1,289 @@
+// Package blake3 is a pure Go BLAKE3 (hash mode, 32 byte digest), ported from
+// the BLAKE3 reference implementation (reference_impl.rs).
+//
+// Besides the streaming hasher it exposes the tree primitives: the chaining
+// value of a chunk, of a parent node, and the root of either. The digest of
+// an input is the root of the tree over its 1 KiB chunks.
+package blake3
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+const (
+	Size      = 32   // digest size
+	BlockSize = 64   // compression block size
+	ChunkSize = 1024 // leaf (chunk) size of the tree
+
+	chunkStart = 1 << 0
+	chunkEnd   = 1 << 1
+	parent     = 1 << 2
+	root       = 1 << 3
+)
+
+var iv = [8]uint32{
+	0x6A09E667, 0xBB67AE85, 0x3C6EF372, 0xA54FF53A, 0x510E527F, 0x9B05688C, 0x1F83D9AB, 0x5BE0CD19,
+}
+
+var msgPermutation = [16]int{2, 6, 3, 10, 7, 0, 4, 13, 1, 11, 12, 5, 9, 14, 15, 8}
+
+// Mixing function.
+func g(state *[16]uint32, a, b, c, d int, mx, my uint32) {
+	state[a] += state[b] + mx
+	state[d] = bits.RotateLeft32(state[d]^state[a], -16)
+	state[c] += state[d]
+	state[b] = bits.RotateLeft32(state[b]^state[c], -12)
+	state[a] += state[b] + my
+	state[d] = bits.RotateLeft32(state[d]^state[a], -8)
+	state[c] += state[d]
+	state[b] = bits.RotateLeft32(state[b]^state[c], -7)
+}
+
+func round(state *[16]uint32, m *[16]uint32) {
+	// columns
+	g(state, 0, 4, 8, 12, m[0], m[1])
+	g(state, 1, 5, 9, 13, m[2], m[3])
+	g(state, 2, 6, 10, 14, m[4], m[5])
+	g(state, 3, 7, 11, 15, m[6], m[7])
+	// diagonals
+	g(state, 0, 5, 10, 15, m[8], m[9])
+	g(state, 1, 6, 11, 12, m[10], m[11])
+	g(state, 2, 7, 8, 13, m[12], m[13])
+	g(state, 3, 4, 9, 14, m[14], m[15])
+}
+
+func permute(m *[16]uint32) {
+	var permuted [16]uint32
+	for i := range permuted {
+		permuted[i] = m[msgPermutation[i]]
+	}
+	*m = permuted
+}
+
+func compress(cv *[8]uint32, block *[16]uint32, counter uint64, blockLen, flags uint32) [16]uint32 {
+	state := [16]uint32{
+		cv[0], cv[1], cv[2], cv[3], cv[4], cv[5], cv[6], cv[7],
+		iv[0], iv[1], iv[2], iv[3],
+		uint32(counter), uint32(counter >> 32), blockLen, flags,
+	}
+	m := *block
+
+	for r := 0; r < 7; r++ {
+		round(&state, &m)
+		if r < 6 {
+			permute(&m)
+		}
+	}
+
+	for i := 0; i < 8; i++ {
+		state[i] ^= state[i+8]
+		state[i+8] ^= cv[i]
+	}
+
+	return state
+}
+
+func wordsFromBytes(b []byte, words []uint32) {
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+}
+
+func bytesFromCV(cv [8]uint32) []byte {
+	b := make([]byte, Size)
+	for i, word := range cv {
+		binary.LittleEndian.PutUint32(b[4*i:], word)
+	}
+	return b
+}
+
+func cvFromBytes(b []byte) [8]uint32 {
+	var cv [8]uint32
+	wordsFromBytes(b, cv[:])
+	return cv
+}
+
+// Last compression of a node: its chaining value, or the root.
+type output struct {
+	inputCV  [8]uint32
+	block    [16]uint32
+	counter  uint64
+	blockLen uint32
+	flags    uint32
+}
+
+func (o *output) chainingValue() [8]uint32 {
+	state := compress(&o.inputCV, &o.block, o.counter, o.blockLen, o.flags)
+	return [8]uint32(state[:8])
+}
+
+func (o *output) rootBytes() []byte {
+	state := compress(&o.inputCV, &o.block, 0, o.blockLen, o.flags|root)
+	return bytesFromCV([8]uint32(state[:8]))
+}
+
+type chunkState struct {
+	cv               [8]uint32
+	counter          uint64
+	block            [BlockSize]byte
+	blockLen         int
+	blocksCompressed int
+}
+
+func newChunkState(counter uint64) chunkState {
+	return chunkState{cv: iv, counter: counter}
+}
+
+func (cs *chunkState) len() int {
+	return BlockSize*cs.blocksCompressed + cs.blockLen
+}
+
+func (cs *chunkState) startFlag() uint32 {
+	if cs.blocksCompressed == 0 {
+		return chunkStart
+	}
+	return 0
+}
+
+func (cs *chunkState) update(input []byte) {
+	for len(input) > 0 {
+		// full block, more input: compress it (the last block is kept for output)
+		if cs.blockLen == BlockSize {
+			var words [16]uint32
+			wordsFromBytes(cs.block[:], words[:])
+			state := compress(&cs.cv, &words, cs.counter, BlockSize, cs.startFlag())
+			cs.cv = [8]uint32(state[:8])
+			cs.blocksCompressed++
+			cs.block = [BlockSize]byte{}
+			cs.blockLen = 0
+		}
+
+		n := copy(cs.block[cs.blockLen:], input)
+		cs.blockLen += n
+		input = input[n:]
+	}
+}
+
+func (cs *chunkState) output() output {
+	o := output{
+		inputCV:  cs.cv,
+		counter:  cs.counter,
+		blockLen: uint32(cs.blockLen),
+		flags:    cs.startFlag() | chunkEnd,
+	}
+	wordsFromBytes(cs.block[:], o.block[:])
+	return o
+}
+
+func parentOutput(left, right [8]uint32) output {
+	o := output{inputCV: iv, blockLen: BlockSize, flags: parent}
+	copy(o.block[:8], left[:])
+	copy(o.block[8:], right[:])
+	return o
+}
+
+func chunkOutput(chunk []byte, counter uint64) output {
+	cs := newChunkState(counter)
+	cs.update(chunk)
+	return cs.output()
+}
+
+// Chaining value of the chunk at index counter (not the root: the tree has other chunks).
+func ChunkChainingValue(chunk []byte, counter uint64) []byte {
+	o := chunkOutput(chunk, counter)
+	return bytesFromCV(o.chainingValue())
+}
+
+// Chaining value of a parent node (not the root).
+func ParentChainingValue(left, right []byte) []byte {
+	o := parentOutput(cvFromBytes(left), cvFromBytes(right))
+	return bytesFromCV(o.chainingValue())
+}
+
+// Root (digest) of a single chunk input.
+func ChunkRoot(chunk []byte) []byte {
+	o := chunkOutput(chunk, 0)
+	return o.rootBytes()
+}
+
+// Root (digest) of a tree from its two top chaining values.
+func ParentRoot(left, right []byte) []byte {
+	o := parentOutput(cvFromBytes(left), cvFromBytes(right))
+	return o.rootBytes()
+}
+
+// Streaming BLAKE3 hasher, implements hash.Hash.
+type Hasher struct {
+	chunk   chunkState
+	cvStack [][8]uint32
+}
+
+var _ hash.Hash = (*Hasher)(nil)
+
+func New() *Hasher {
+	return &Hasher{chunk: newChunkState(0)}
+}
+
+// Digest of data.
+func Sum256(data []byte) [Size]byte {
+	h := New()
+	h.Write(data)
+	return [Size]byte(h.Sum(nil))
+}
+
+// Merge the completed subtrees: one per trailing zero bit of the chunk count.
+func (h *Hasher) addChunkCV(cv [8]uint32, totalChunks uint64) {
+	for totalChunks&1 == 0 {
+		top := h.cvStack[len(h.cvStack)-1]
+		h.cvStack = h.cvStack[:len(h.cvStack)-1]
+		o := parentOutput(top, cv)
+		cv = o.chainingValue()
+		totalChunks >>= 1
+	}
+	h.cvStack = append(h.cvStack, cv)
+}
+
+func (h *Hasher) Write(input []byte) (int, error) {
+	written := len(input)
+
+	for len(input) > 0 {
+		// full chunk, more input: it is not the last one
+		if h.chunk.len() == ChunkSize {
+			o := h.chunk.output()
+			totalChunks := h.chunk.counter + 1
+			h.addChunkCV(o.chainingValue(), totalChunks)
+			h.chunk = newChunkState(totalChunks)
+		}
+
+		n := min(ChunkSize-h.chunk.len(), len(input))
+		h.chunk.update(input[:n])
+		input = input[n:]
+	}
+
+	return written, nil
+}
+
+// Appends the digest to b. The state is not changed.
+func (h *Hasher) Sum(b []byte) []byte {
+	o := h.chunk.output()
+	for i := len(h.cvStack) - 1; i >= 0; i-- {
+		o = parentOutput(h.cvStack[i], o.chainingValue())
+	}
+
+	return append(b, o.rootBytes()...)
+}
+
+func (h *Hasher) Reset() {
+	h.chunk = newChunkState(0)
+	h.cvStack = h.cvStack[:0]
+}
+
+func (h *Hasher) Size() int {
+	return Size
+}
+
+func (h *Hasher) BlockSize() int {
+	return BlockSize
+}
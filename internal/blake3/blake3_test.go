@@ -0,0 +1,64 @@
+package blake3
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// BLAKE3 test vectors (test_vectors.json): input byte i is i % 251.
+var vectors = []struct {
+	length int
+	digest string
+}{
+	{0, "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"},
+	{1, "2d3adedff11b61f14c886e35afa036736dcd87a74d27b5c1510225d0f592e213"},
+	{1024, "42214739f095a406f3fc83deb889744ac00df831c10daa55189b5d121c855af7"},
+	{1025, "d00278ae47eb27b34faecf67b4fe263f82d5412916c1ffd97c8cb7fb814b8444"},
+	{2048, "e776b6028c7cd22a4d0ba182a8bf62205d2ef576467e838ed6f2529b85fba24a"},
+	{3072, "b98cb0ff3623be03326b373de6b9095218513e64f1ee2edd2525c7ad1e5cffd2"},
+	{4096, "015094013f57a5277b59d8475c0501042c0b642e531b0a1c8f58d2163229e969"},
+	{8192, "aae792484c8efe4f19e2ca7d371d8c467ffb10748d8a5a1ae579948f718a2a63"},
+	{31744, "62b6960e1a44bcc1eb1a611a8d6235b6b4b78f32e7abc4fb4c6cdcce94895c47"},
+}
+
+func input(length int) []byte {
+	data := make([]byte, length)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+func TestSum256(t *testing.T) {
+	for _, v := range vectors {
+		digest := Sum256(input(v.length))
+		if hex.EncodeToString(digest[:]) != v.digest {
+			t.Errorf("length %d: (out) got %x, wanted %q", v.length, digest, v.digest)
+		}
+
+		// streaming, in odd sized writes
+		h := New()
+		data := input(v.length)
+		for len(data) > 0 {
+			n := min(7, len(data))
+			h.Write(data[:n])
+			data = data[n:]
+		}
+		if output := hex.EncodeToString(h.Sum(nil)); output != v.digest {
+			t.Errorf("length %d: (streaming) got %q, wanted %q", v.length, output, v.digest)
+		}
+	}
+}
+
+func TestTreePrimitives(t *testing.T) {
+	data := input(2048)
+	left := ChunkChainingValue(data[:ChunkSize], 0)
+	right := ChunkChainingValue(data[ChunkSize:], 1)
+
+	if output := hex.EncodeToString(ParentRoot(left, right)); output != vectors[4].digest {
+		t.Errorf("(parent root) got %q, wanted %q", output, vectors[4].digest)
+	}
+	if output := hex.EncodeToString(ChunkRoot(input(1024))); output != vectors[2].digest {
+		t.Errorf("(chunk root) got %q, wanted %q", output, vectors[2].digest)
+	}
+}
@@ -0,0 +1,173 @@
+package merkletree
+
+//
+// Level layout.
+//
+// The nodes computed by a job live in one flat buffer of fixed size digests (DigestSize):
+// node i of the current level is in slot i. The next level is computed into the first
+// slots of the buffer, in place: node start+p, the hash of nodes start+2p and start+2p+1,
+// overwrites a slot whose node was already hashed. No placeholders, no compaction pass,
+// one buffer per job (plus a scratch one for the parallel and custom layouts).
+//
+// ms.Leaves holds the headers of the current level's nodes: slots of the buffer, or
+// the leaves themselves while they are not hashed (a leaf that is not a digest,
+// ie: promoted by PassThrough, stays a header to the caller's leaf, never copied).
+//
+
+import (
+	"sync"
+)
+
+// Combine left and right into dst, a slot of the digest buffer.
+type pairFunc func(worker *MerkleService, dst, left, right []byte)
+
+// The service's node hashing (see hashPair), written to dst directly.
+func hashPairInto(worker *MerkleService, dst, left, right []byte) {
+	worker.appendPair(dst[:0], left, right)
+}
+
+// Slot i of the digest buffer.
+func (ms *MerkleService) slot(i int) []byte {
+	from, to := i*ms.digestSize, (i+1)*ms.digestSize
+	return ms.nodes[from:to:to]
+}
+
+// Digest buffer of at least count slots (allocated once per job, for its first level).
+func (ms *MerkleService) growNodes(count int) {
+	if len(ms.nodes) < count*ms.digestSize {
+		ms.nodes = make([]byte, count*ms.digestSize)
+	}
+}
+
+// Scratch buffer of at least count slots.
+func (ms *MerkleService) scratchSlots(count int) []byte {
+	if len(ms.scratch) < count*ms.digestSize {
+		ms.scratch = make([]byte, count*ms.digestSize)
+	}
+	return ms.scratch[:count*ms.digestSize]
+}
+
+// Move node (promoted as is) to slot i: a digest is copied, anything else stays a header.
+func (ms *MerkleService) promote(i int, node []byte) {
+	if len(node) != ms.digestSize {
+		ms.Leaves[i] = node
+		return
+	}
+	copy(ms.slot(i), node)
+	ms.Leaves[i] = ms.slot(i)
+}
+
+// Hash the pairs [start] [start+1], [start+2] [start+3], ... of the level (see pairLevel).
+func (ms *MerkleService) hashLevel(start int) {
+	ms.pairLevel(start, hashPairInto)
+}
+
+// Next level: pair p of the nodes from start on becomes node start+p, an unpaired last
+// node follows it as is, the nodes before start stay where they are.
+//   - ie: start 1
+//     [1] [2] [3] [4] [5] [6] => [1] [23] [45] [6]
+func (ms *MerkleService) pairLevel(start int, combine pairFunc) {
+	count := len(ms.Leaves)
+	pairs := (count - start) / 2
+	ms.growNodes(count)
+
+	if ms.sharded(pairs) {
+		// Shards would overwrite slots other shards still read: hash into the scratch buffer.
+		scratch := ms.scratchSlots(pairs)
+		size := ms.digestSize
+		ms.shard(pairs, func(worker *MerkleService, from, to int) {
+			for pair := from; pair < to; pair++ {
+				index := start + 2*pair
+				combine(worker, scratch[pair*size:(pair+1)*size], ms.Leaves[index], ms.Leaves[index+1])
+			}
+		})
+		copy(ms.nodes[start*size:], scratch)
+	} else {
+		// Slot start+p is overwritten after nodes start+2p and start+2p+1 were read.
+		for pair := 0; pair < pairs; pair++ {
+			index := start + 2*pair
+			combine(ms, ms.slot(start+pair), ms.Leaves[index], ms.Leaves[index+1])
+		}
+	}
+
+	for pair := 0; pair < pairs; pair++ {
+		ms.Leaves[start+pair] = ms.slot(start + pair)
+	}
+	next := start + pairs
+
+	// - unpaired last node: pass it through to the next level
+	if (count-start)%2 == 1 {
+		ms.promote(next, ms.Leaves[count-1])
+		next++
+	}
+
+	ms.Leaves = ms.Leaves[:next]
+}
+
+// Pad an odd level with the node its unpaired last node is hashed with (see OddNodePolicy).
+// A promoted node is left alone: pairLevel passes it through.
+//   - ie: DuplicateOddNodes
+//     [1] [2] [3] [4] [5] => [1] [2] [3] [4] [5] [5]
+func (ms *MerkleService) padOddLevel() {
+	count := len(ms.Leaves)
+	if count%2 == 0 {
+		return
+	}
+
+	switch ms.oddNodes {
+	case DuplicateOddNodes:
+		ms.Leaves = append(ms.Leaves, ms.Leaves[count-1])
+	case ZeroPadOddNodes, ConstantPadOddNodes:
+		ms.Leaves = append(ms.Leaves, ms.oddNodePad)
+	}
+}
+
+// Root of a single leaf (see SingleLeafPolicy).
+func (ms *MerkleService) singleLeafRoot() error {
+	switch ms.singleLeaf {
+	case RejectSingleLeaf:
+		return &ArgumentErr{"single leaf - "}
+
+	case HashSingleLeaf:
+		ms.Leaves[0] = ms.hashPair(ms.Leaves[0], nil)
+		ms.recordLevel()
+
+	case PairSingleLeaf:
+		if ms.oddNodes != PromoteOddNodes {
+			ms.padOddLevel()
+			ms.proveLevel(0)
+			ms.hashLevel(0)
+			ms.recordLevel()
+		}
+	}
+
+	ms.ProcessResult = ms.Leaves[0]
+
+	return nil
+}
+
+// Are count items split across workers (see WithWorkers)?
+func (ms *MerkleService) sharded(count int) bool {
+	return min(ms.workers, count/ParallelMinPairs) > 1
+}
+
+// Run fn over [0, count): with workers (see WithWorkers), in one shard per worker.
+// Hashers are safe for concurrent use, shards write to distinct indices only.
+func (ms *MerkleService) shard(count int, fn func(worker *MerkleService, from, to int)) {
+	if !ms.sharded(count) {
+		fn(ms, 0, count)
+		return
+	}
+
+	var wg sync.WaitGroup
+	workers := min(ms.workers, count/ParallelMinPairs)
+	size := (count + workers - 1) / workers
+	for from := 0; from < count; from += size {
+		wg.Add(1)
+		go func(from, to int) {
+			defer wg.Done()
+			fn(ms, from, to)
+		}(from, min(from+size, count))
+	}
+	wg.Wait()
+}
@@ -0,0 +1,88 @@
+package merkletree
+
+import (
+	"bytes"
+	"math/bits"
+	"testing"
+)
+
+// Reference levels: a new slice per level, a new slice per node.
+func referenceLevel(nodes [][]byte, start int) [][]byte {
+	next := append([][]byte{}, nodes[:start]...)
+	for i := start; i < len(nodes); i += 2 {
+		if i+1 == len(nodes) {
+			next = append(next, nodes[i])
+			break
+		}
+		next = append(next, SHA256SUM256(append(bytes.Clone(nodes[i]), nodes[i+1]...)))
+	}
+	return next
+}
+
+func referenceRoot(nodes [][]byte, processType int) []byte {
+	switch processType {
+	case DupeAppend:
+		for started := false; !started || len(nodes) > 1; started = true {
+			if len(nodes)%2 == 1 {
+				nodes = append(nodes, nodes[len(nodes)-1])
+			}
+			nodes = referenceLevel(nodes, 0)
+		}
+	case BinaryTree:
+		nodes = referenceLevel(nodes, 1<<bits.Len(uint(len(nodes)-1))-len(nodes))
+	}
+	for len(nodes) > 1 {
+		nodes = referenceLevel(nodes, 0)
+	}
+	return nodes[0]
+}
+
+func TestLevelBuffer(t *testing.T) {
+	for count := 2; count <= 40; count++ {
+		// leaves of any length: promoted ones are not digests
+		leaves := make([][]byte, count)
+		for i := range leaves {
+			leaves[i] = bytes.Repeat([]byte{byte(i)}, 1+i%45)
+		}
+
+		for _, processType := range []int{PassThrough, DupeAppend, BinaryTree} {
+			expected := referenceRoot(leaves, processType)
+
+			ms, _ := New(WithProcessType(processType))
+			output, err := ms.Derive(leaves)
+			if err != nil {
+				t.Fatalf("process %d, count %d: (err) got %q, wanted nil", processType, count, err)
+			}
+			if !bytes.Equal(output, expected) {
+				t.Errorf("process %d, count %d: (out) got %x, wanted %x", processType, count, output, expected)
+			}
+
+			// retained levels are copies, not the reused digest buffer
+			tree, _ := ms.BuildTree(leaves)
+			if !bytes.Equal(tree.Root(), expected) {
+				t.Errorf("process %d, count %d: (tree) got %x, wanted %x", processType, count, tree.Root(), expected)
+			}
+			for level := 1; level < tree.Depth(); level++ {
+				nodes, _ := tree.Level(level)
+				if !bytes.Equal(nodes[0], referenceNode(leaves, processType, level)) {
+					t.Errorf("process %d, count %d: level %d overwritten", processType, count, level)
+				}
+			}
+		}
+	}
+}
+
+// First node of a level, by the reference.
+func referenceNode(nodes [][]byte, processType, level int) []byte {
+	for l := 0; l < level; l++ {
+		start := 0
+		switch {
+		case processType == DupeAppend && len(nodes)%2 == 1:
+			nodes = append(nodes, nodes[len(nodes)-1])
+		case processType == BinaryTree && l == 0:
+			start = 1<<bits.Len(uint(len(nodes)-1)) - len(nodes)
+		}
+		nodes = referenceLevel(nodes, start)
+	}
+	return nodes[0]
+}
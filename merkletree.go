@@ -15,23 +15,40 @@ package merkletree
 //	- DeriveRoot:
 //	  --> This is the entry point to use this service.
 //
-//	- GetMerkletreeRoot:
-//	  	Merkletree service configuration setup:
-//			- Check if initial data (leaves) is available and of correct type.
+//	- DeriveRootContext:
+//	  --> Same as DeriveRoot, but runs under the caller's context (deadline, cancellation).
+//
+//	- New:
+//	  	Merkletree service configuration setup (see options.go):
 //			- Validate requested algorithm (hash) exists and set related function.
-//			- Check initial encoding flag.
 //			- Validate process type requested.
-//			- Init Merkletree Structure.
+//			- Register process type functions.
+//
+//	- Derive, DeriveContext:
+//			- Check if initial data (leaves) is available.
 //			- Hash all elements of first branch, if requested.
 //			- Execute requested tree manipulation request.
 //
+//	- GenerateProof (inclusionProof.go):
+//	  	Inclusion proof (audit path) of a leaf.
+//
+//	- BuildTree (tree.go):
+//	  	Tree with all of its levels retained.
+//
+//	- DetectMutation (duplicateAndAppendProcess.go):
+//	  	Duplicated trailing pair colliding with the odd level duplication (CVE-2012-2459).
+//
+//	- ConsistencyProof, VerifyConsistency (consistencyProof.go):
+//	  	Proof that a tree is a prefix of a larger one (append-only logs).
+//
 // Helper/auxilary functions:
 //
-//	- removeNillBytes:
-//		Removes all empty []byte{} elements from hash slice.
+//	- pairLevel, hashLevel (levelBuffer.go):
+//		Computes the next level into the job's flat digest buffer, in place.
 //
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"slices"
@@ -45,15 +62,65 @@ const (
 	PassThrough                        = 0
 	DupeAppend                         = 1
 	BinaryTree                         = 2
+	RFC6962                            = 3
+	StandardMerkleTree                 = 4
+	Bitcoin                            = 5
+	Monero                             = 6
+	BLAKE3Tree                         = 7
 	ProcessTimeoutMilliSecs            = 100
+	ParallelMinPairs                   = 1024
+	DefaultAlgorithm                   = "SHA256SUM256"
 	contextKeyRequestID     contextKey = iota
 )
 
 // CTX key values
 var (
-	processTypes = [3]string{"PAS-THRU", "DUP-APND", "BIN-TREE"}
+	processTypes = [8]string{"PAS-THRU", "DUP-APND", "BIN-TREE", "RFC-6962", "OZ-STD", "BTC-BLK", "XMR-TREE", "B3-CHUNK"}
+
+	// Process types bound to an algorithm (also their default)
+	processTypeAlgorithms = map[int]string{
+		StandardMerkleTree: "KECCAK256",
+		Bitcoin:            "SHA256D",
+		Monero:             "KECCAK256",
+		BLAKE3Tree:         "BLAKE3",
+	}
+)
+
+// Empty leaf policies (see WithEmptyLeaves)
+const (
+	DefaultEmptyLeaves    EmptyLeafPolicy = iota // hashed if leaves are hashed, rejected otherwise
+	RejectEmptyLeaves                            // EmptyLeafErr
+	HashEmptyLeaves                              // digest of the empty message (preceded by the leaf prefix, if any)
+	ZeroDigestEmptyLeaves                        // DigestSize zero bytes
 )
 
+// What an empty (zero length) leaf stands for.
+type EmptyLeafPolicy int
+
+// Odd node policies (see WithOddNodes), the unpaired last node of a level is:
+const (
+	DefaultOddNodes     OddNodePolicy = iota // the process type's own
+	PromoteOddNodes                          // moved up to the next level as is
+	DuplicateOddNodes                        // hashed with itself
+	ZeroPadOddNodes                          // hashed with a zero digest
+	ConstantPadOddNodes                      // hashed with a constant (see WithOddNodeConstant)
+)
+
+// What happens to the unpaired last node of a level.
+type OddNodePolicy int
+
+// Single leaf policies (see WithSingleLeaf), the root of a single leaf is:
+const (
+	DefaultSingleLeaf SingleLeafPolicy = iota // the process type's own
+	SingleLeafAsRoot                          // the leaf itself (hashed, if leaves are hashed)
+	PairSingleLeaf                            // the leaf paired as an odd node (see OddNodePolicy)
+	HashSingleLeaf                            // digest of the leaf (preceded by the node prefix, if any)
+	RejectSingleLeaf                          // none: ArgumentErr
+)
+
+// What the root of a tree of one leaf is.
+type SingleLeafPolicy int
+
 // CTX key
 type contextKey int
 
@@ -63,18 +130,216 @@ type Response struct {
 }
 
 // Process type function signature
-type processTypeFunction func(context.Context) error
+type processTypeFunction func(*MerkleService, context.Context) error
 
 // Merkle tree object
 type MerkleService struct {
 	Leaves              [][]byte                    `json:"-"`
 	HashTypeID          string                      `json:"hashtype"`
-	hashGenerator       CryptoFunc                  `json:"-"`
+	algorithmSet        bool                        `json:"-"`
+	algorithm           string                      `json:"-"`
+	digestSize          int                         `json:"-"`
+	customization       []byte                      `json:"-"`
+	hasher              Hasher                      `json:"-"`
 	ProcessType         int                         `json:"processtype"`
 	ProcessTypeRegistry map[int]processTypeFunction `json:"-"`
+	processType         int                         `json:"-"`
+	emptyLeaves         EmptyLeafPolicy             `json:"-"`
+	oddNodes            OddNodePolicy               `json:"-"`
+	singleLeaf          SingleLeafPolicy            `json:"-"`
+	oddNodePad          []byte                      `json:"-"`
+	processName         string                      `json:"-"`
+	strategy            Strategy                    `json:"-"`
+	timeout             time.Duration               `json:"-"`
+	workers             int                         `json:"-"`
+	leafHashing         bool                        `json:"-"`
+	sortedPairs         bool                        `json:"-"`
+	strictMutation      bool                        `json:"-"`
+	doubleLeafHash      bool                        `json:"-"`
+	reverseByteOrder    bool                        `json:"-"`
+	leafPrefix          []byte                      `json:"-"`
+	nodePrefix          []byte                      `json:"-"`
 	ProofRequest        bool                        `json:"proofrequest"`
+	proofIndex          int                         `json:"-"`
+	retainLevels        bool                        `json:"-"`
+	levels              [][][]byte                  `json:"-"`
+	nodes               []byte                      `json:"-"`
+	scratch             []byte                      `json:"-"`
 	ProcessResult       []byte                      `json:"root"`
-	ProofResult         []byte                      `json:"proofresult"`
+	Mutated             bool                        `json:"mutated"`
+	ProofResult         *Proof                      `json:"proofresult"`
+}
+
+/*
+Constructor
+  - Applies the options, validates the configuration once and registers the
+    process type functions. The service can be reused for many Derive calls.
+*/
+func New(opts ...Option) (*MerkleService, error) {
+	ms := &MerkleService{
+		ProcessType: PassThrough,
+		timeout:     time.Millisecond * ProcessTimeoutMilliSecs,
+	}
+
+	for _, opt := range opts {
+		opt(ms)
+	}
+
+	// Default algorithm (WithAlgorithm not used): the process type's own, if it has one.
+	// An algorithm requested, even empty, is validated as is.
+	if !ms.algorithmSet {
+		ms.HashTypeID = DefaultAlgorithm
+		if algorithm, ok := processTypeAlgorithms[ms.ProcessType]; ok {
+			ms.HashTypeID = algorithm
+		}
+	}
+
+	// Validate configuration
+	if err := validateArgs(ms.HashTypeID, ms.ProcessType, ms.leafPrefix, ms.nodePrefix, ms.digestSize, ms.customization); err != nil {
+		return nil, err
+	}
+	if err := validatePolicies(ms.ProcessType, ms.emptyLeaves, ms.oddNodes, ms.oddNodePad, ms.singleLeaf); err != nil {
+		return nil, err
+	}
+	ms.HashTypeID = strings.ToUpper(ms.HashTypeID)
+
+	// XOF: the digest size (and customization) are bound to the hasher.
+	fixed, xof := lookupAlgorithm(ms.HashTypeID)
+	switch {
+	case xof != nil:
+		if ms.digestSize == 0 {
+			metadata, _ := AlgorithmInfo(ms.HashTypeID)
+			ms.digestSize = metadata.DigestSize
+		}
+		ms.hasher = newXOFHasher(ms.HashTypeID, xof, ms.digestSize, ms.customization)
+	case fixed != nil:
+		ms.hasher = fixed
+		ms.digestSize = fixed.Size()
+	default:
+		// unregistered since validation
+		return nil, &ArgumentErr{"unknown algorithm - "}
+	}
+
+	// RFC 6962: leaves and nodes are always domain separated, with its own prefixes.
+	if ms.ProcessType == RFC6962 {
+		ms.leafPrefix = []byte{RFC6962LeafPrefix}
+		ms.nodePrefix = []byte{RFC6962NodePrefix}
+	}
+
+	// OpenZeppelin StandardMerkleTree: leaves are double hashed, pairs are sorted.
+	if ms.ProcessType == StandardMerkleTree {
+		ms.leafHashing = true
+		ms.doubleLeafHash = true
+		ms.sortedPairs = true
+	}
+
+	// Bitcoin: txids and root in display order (reversed), leaves are not hashed.
+	if ms.ProcessType == Bitcoin {
+		ms.reverseByteOrder = true
+		ms.leafHashing = false
+	}
+
+	// Domain separation: leaves are hashed (with the leaf prefix).
+	if ms.nodePrefix != nil {
+		ms.leafHashing = true
+	}
+
+	// BLAKE3 chunk tree: the chunks are compressed as is, nodes are BLAKE3's own.
+	if ms.ProcessType == BLAKE3Tree {
+		ms.leafHashing = false
+		ms.leafPrefix, ms.nodePrefix = nil, nil
+	}
+
+	// Empty leaves: hashed as any other leaf if leaves are hashed, rejected otherwise.
+	// (BLAKE3 chunk tree: chunks, not leaves; an empty input is a single empty chunk.)
+	if ms.emptyLeaves == DefaultEmptyLeaves && ms.ProcessType != BLAKE3Tree {
+		ms.emptyLeaves = If(ms.leafHashing, HashEmptyLeaves, RejectEmptyLeaves)
+	}
+
+	// Odd nodes: duplicated by DupeAppend and Bitcoin, promoted otherwise.
+	// Single leaf: paired with itself by DupeAppend, the root otherwise.
+	if ms.oddNodes == DefaultOddNodes {
+		ms.oddNodes = If(ms.ProcessType == DupeAppend || ms.ProcessType == Bitcoin, DuplicateOddNodes, PromoteOddNodes)
+	}
+	if ms.singleLeaf == DefaultSingleLeaf {
+		ms.singleLeaf = If(ms.ProcessType == DupeAppend, PairSingleLeaf, SingleLeafAsRoot)
+	}
+	if ms.oddNodes == ZeroPadOddNodes {
+		ms.oddNodePad = make([]byte, ms.digestSize)
+	}
+
+	// Register process type functions
+	ms.ProcessTypeRegistry = map[int]processTypeFunction{
+		0: (*MerkleService).processPassThroughRequest,
+		1: (*MerkleService).processDuplicateAndAppendRequest,
+		2: (*MerkleService).processBinaryTreeRequest,
+		3: (*MerkleService).processRFC6962Request,
+		4: (*MerkleService).processStandardMerkleTreeRequest,
+		5: (*MerkleService).processBitcoinRequest,
+		6: (*MerkleService).processMoneroRequest,
+		7: (*MerkleService).processBLAKE3TreeRequest,
+	}
+
+	// Custom process type: laid out by its strategy (see RegisterProcessType).
+	if custom, ok := lookupProcessType(ms.ProcessType); ok {
+		ms.processName = custom.name
+		ms.strategy = custom.strategy
+		ms.ProcessTypeRegistry[ms.ProcessType] = strategyProcess(custom)
+	} else {
+		ms.processName = processTypes[ms.ProcessType]
+	}
+
+	// As validated (see checkConfig)
+	ms.algorithm, ms.processType = ms.HashTypeID, ms.ProcessType
+
+	return ms, nil
+}
+
+// Configuration, read only: it is set by the options of New, and validated there.
+
+// Digest size in bytes.
+func (ms *MerkleService) DigestSize() int {
+	return ms.digestSize
+}
+
+// Empty leaf policy (see WithEmptyLeaves), resolved: never DefaultEmptyLeaves, but for BLAKE3Tree.
+func (ms *MerkleService) EmptyLeaves() EmptyLeafPolicy {
+	return ms.emptyLeaves
+}
+
+// Odd node policy (see WithOddNodes), resolved.
+func (ms *MerkleService) OddNodes() OddNodePolicy {
+	return ms.oddNodes
+}
+
+// Single leaf policy (see WithSingleLeaf), resolved.
+func (ms *MerkleService) SingleLeaf() SingleLeafPolicy {
+	return ms.singleLeaf
+}
+
+// Timeout of a request, 0: none (see WithTimeout).
+func (ms *MerkleService) Timeout() time.Duration {
+	return ms.timeout
+}
+
+// Workers hashing a level (see WithWorkers).
+func (ms *MerkleService) Workers() int {
+	return ms.workers
+}
+
+// Whether leaves are hashed (see WithLeafHashing), as the process type says.
+func (ms *MerkleService) LeafHashing() bool {
+	return ms.leafHashing
+}
+
+// Whether pairs are sorted before hashing (see WithSortedPairs).
+func (ms *MerkleService) SortedPairs() bool {
+	return ms.sortedPairs
+}
+
+// Whether DetectMutation reports any duplicated trailing pair (see WithStrictMutation).
+func (ms *MerkleService) StrictMutation() bool {
+	return ms.strictMutation
 }
 
 /*
@@ -82,72 +347,201 @@ Entry Point
 - Merkletree service configuration setup and start of request.
 */
 func DeriveRoot(hashes [][]byte, algorithmRequested string, processType int) ([]byte, error) {
-	// Validate arguments
-	if err := validateArgs(hashes, algorithmRequested, processType); err != nil {
+	ms, err := New(WithAlgorithm(algorithmRequested), WithProcessType(processType))
+	if err != nil {
 		return []byte{}, err
 	}
 
-	// Initialize merkle pertinents.
-	ms := &MerkleService{
-		Leaves:        hashes,
-		HashTypeID:    algorithmRequested,
-		hashGenerator: AlgorithmRegistry[algorithmRequested],
-		ProcessType:   processType,
+	return ms.Derive(hashes)
+}
+
+/*
+Entry Point (context aware)
+  - Same as DeriveRoot, but the deadline and cancellation are the caller's.
+    No default timeout is imposed.
+*/
+func DeriveRootContext(ctx context.Context, hashes [][]byte, algorithmRequested string, processType int) ([]byte, error) {
+	ms, err := New(WithAlgorithm(algorithmRequested), WithProcessType(processType), WithTimeout(0))
+	if err != nil {
+		return []byte{}, err
 	}
 
-	// Registe process type functions
-	ms.ProcessTypeRegistry = map[int]processTypeFunction{
-		0: ms.processPassThroughRequest,
-		1: ms.processDuplicateAndAppendRequest,
-		2: ms.processBinaryTreeRequest,
+	return ms.DeriveContext(ctx, hashes)
+}
+
+// Derive the merkle root of hashes with the service's configuration.
+func (ms *MerkleService) Derive(hashes [][]byte) ([]byte, error) {
+	return ms.DeriveContext(context.Background(), hashes)
+}
+
+// Derive the merkle root of hashes under ctx. The service's timeout, if any, is applied on top of it.
+func (ms *MerkleService) DeriveContext(ctx context.Context, hashes [][]byte) ([]byte, error) {
+	job, err := ms.newJob(hashes)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	if err := ms.execute(ctx, job); err != nil {
+		return []byte{}, err
+	}
+
+	return job.ProcessResult, nil
+}
+
+// Per request copy of the service: the service itself holds no request state.
+//   - The caller's data is never written to: the job works on its own copy of the
+//     slice (see prepareLeaves), and nodes are written to the job's own digest buffer
+//     (see levelBuffer.go).
+//   - Empty leaves are rejected here; copying, hashing and replacing leaves is left
+//     to prepareLeaves, under the request's ctx.
+func (ms *MerkleService) newJob(hashes [][]byte) (*MerkleService, error) {
+	// check if we got something to work with.
+	if len(hashes) == 0 {
+		return nil, &ArgumentErr{"empty data - "}
+	}
+	if err := ms.checkConfig(); err != nil {
+		return nil, err
+	}
+	if err := ms.checkLeaves(hashes...); err != nil {
+		return nil, err
+	}
+
+	job := *ms
+	job.Leaves = hashes
+
+	return &job, nil
+}
+
+// The exported configuration fields are New's: one changed since is rejected, not run
+// (an algorithm's hasher, a process type's defaults and policies are bound by New).
+func (ms *MerkleService) checkConfig() error {
+	if ms.HashTypeID != ms.algorithm {
+		return &ArgumentErr{fmt.Sprintf("algorithm changed to %q since New - ", ms.HashTypeID)}
+	}
+	if ms.ProcessType != ms.processType {
+		return &ArgumentErr{fmt.Sprintf("process type changed to %d since New - ", ms.ProcessType)}
+	}
+	if process := ms.ProcessTypeRegistry[ms.ProcessType]; process == nil {
+		return &ArgumentErr{"invalid process type - "}
+	}
+	return nil
+}
+
+// Leaves between two checks of ctx while preparing them.
+const prepareCheckLeaves = 1024
+
+// Copy the job's leaves, then hash, prefix or replace them as the service says (see prepareLeaf).
+//   - Runs under the request's ctx (see execute): every shard checks it every
+//     prepareCheckLeaves leaves and stops once it is done.
+//   - Records the prepared leaves as level 0 (see recordLevel).
+func (ms *MerkleService) prepareLeaves(ctx context.Context) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	ms.Leaves = slices.Clone(ms.Leaves)
+	if ms.leafHashing || ms.reverseByteOrder || ms.emptyLeaves == HashEmptyLeaves || ms.emptyLeaves == ZeroDigestEmptyLeaves {
+		ms.shard(len(ms.Leaves), func(worker *MerkleService, from, to int) {
+			for i := from; i < to; i++ {
+				if (i-from)%prepareCheckLeaves == 0 && ctx.Err() != nil {
+					return
+				}
+				ms.Leaves[i] = worker.prepareLeaf(ms.Leaves[i])
+			}
+		})
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	ms.recordLevel()
+	return nil
+}
+
+// Execute the process type function on job, within the service's timeout.
+func (ms *MerkleService) execute(ctx context.Context, job *MerkleService) error {
+	if err := ms.executeFunc(ctx, job, ms.ProcessTypeRegistry[ms.ProcessType]); err != nil {
+		return err
+	}
+
+	// (a copy: the root must not hold on to the job's digest buffer)
+	job.ProcessResult = job.finalRoot(slices.Clone(job.ProcessResult))
+
+	return nil
+}
+
+// Execute process on job (its leaves prepared first), within the service's timeout.
+func (ms *MerkleService) executeFunc(ctx context.Context, job *MerkleService, process processTypeFunction) error {
+	// Set timeout criteria
+	if ms.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ms.timeout)
+		defer cancel()
 	}
 
-	// Set context process id and timeout criteria
-	ctx := context.WithValue(context.Background(), contextKeyRequestID, processTypes[processType])
-	ctx, cancel := context.WithTimeout(ctx, time.Millisecond*ProcessTimeoutMilliSecs)
-	defer cancel()
+	// Set context process id
+	ctx = context.WithValue(ctx, contextKeyRequestID, ms.processName)
 
-	// Response channel
-	resch := make(chan Response)
+	// Response channel (buffered: the worker never blocks on it once we stopped listening)
+	resch := make(chan Response, 1)
 
 	// Execute desired processtype
 	go func() {
-		err := ms.ProcessTypeRegistry[processType](ctx)
+		err := job.prepareLeaves(ctx)
+		if err == nil {
+			err = process(job, ctx)
+		}
 		resch <- Response{err: err}
 	}()
 
 	// Get results, errors from response channel
 	select {
 	case <-ctx.Done():
-		return []byte{}, &ProcessTimedOutErr{ctx.Err()}
+		return &ProcessTimedOutErr{ctx.Err()}
 	case resp := <-resch:
-		if resp.err != nil {
-			return []byte{}, resp.err
-		}
+		return resp.err
 	}
-
-	return ms.ProcessResult, nil
 }
 
 // Arguments validation
-func validateArgs(data [][]byte, algoReq string, pType int) error {
+func validateArgs(algoReq string, pType int, leafPrefix, nodePrefix []byte, digestSize int, customization []byte) error {
 	var (
 		validationErrs []string
 		sb             strings.Builder
 	)
 
-	// check if we got something to work with.
-	if len(data) == 0 {
-		validationErrs = append(validationErrs, "empty data")
-	}
 	// Validate existing algorithm request
-	if _, ok := AlgorithmRegistry[strings.ToUpper(algoReq)]; !ok {
+	fixedFunc, xofFunc := lookupAlgorithm(strings.ToUpper(algoReq))
+	fixed, xof := fixedFunc != nil, xofFunc != nil
+	if !fixed && !xof {
 		validationErrs = append(validationErrs, "unknown algorithm")
 	}
+	// digest size: XOF only, at least one byte
+	if (digestSize != 0 && !xof) || digestSize < 0 {
+		validationErrs = append(validationErrs, "invalid digest size")
+	}
+	// customization string: cSHAKE only
+	if customization != nil && !strings.HasPrefix(strings.ToUpper(algoReq), "CSHAKE") {
+		validationErrs = append(validationErrs, "customization requires a cSHAKE algorithm")
+	}
 	// is process type within range
-	if pType < PassThrough || pType > BinaryTree {
+	if _, custom := lookupProcessType(pType); !custom && (pType < PassThrough || pType >= len(processTypes)) {
 		validationErrs = append(validationErrs, "invalid process type")
 	}
+	// process type bound to an algorithm
+	if algorithm, ok := processTypeAlgorithms[pType]; ok && strings.ToUpper(algoReq) != algorithm {
+		validationErrs = append(validationErrs, fmt.Sprintf("process type requires %s", algorithm))
+	}
+	// domain separation prefixes: both or none, and neither one a prefix of the other
+	if (leafPrefix == nil) != (nodePrefix == nil) || (leafPrefix != nil &&
+		(bytes.HasPrefix(leafPrefix, nodePrefix) || bytes.HasPrefix(nodePrefix, leafPrefix))) {
+		validationErrs = append(validationErrs, "invalid domain separation prefixes")
+	}
+	// RFC 6962 has its own prefixes: others conflict with them
+	if pType == RFC6962 && leafPrefix != nil &&
+		(!bytes.Equal(leafPrefix, []byte{RFC6962LeafPrefix}) || !bytes.Equal(nodePrefix, []byte{RFC6962NodePrefix})) {
+		validationErrs = append(validationErrs, "process type has its own domain separation prefixes")
+	}
 	// nothing detected: retrun nil
 	if len(validationErrs) == 0 {
 		return nil
@@ -161,38 +555,130 @@ func validateArgs(data [][]byte, algoReq string, pType int) error {
 	return &ArgumentErr{sb.String()}
 }
 
-// Ternary operator
-func If[T any](cond bool, trueReturn, falseReturn T) T {
-	if cond {
-		return trueReturn
+// Policies validation
+func validatePolicies(pType int, emptyLeaves EmptyLeafPolicy, oddNodes OddNodePolicy, oddNodePad []byte, singleLeaf SingleLeafPolicy) error {
+	var (
+		validationErrs []string
+		sb             strings.Builder
+	)
+
+	// level by level pairing: the other process types follow their specification
+	configurable := pType == PassThrough || pType == DupeAppend || pType == BinaryTree
+
+	// empty leaf policy: BLAKE3 chunk trees have their own (see WithEmptyLeaves)
+	if emptyLeaves < DefaultEmptyLeaves || emptyLeaves > ZeroDigestEmptyLeaves ||
+		(pType == BLAKE3Tree && emptyLeaves != DefaultEmptyLeaves) {
+		validationErrs = append(validationErrs, "invalid empty leaf policy")
 	}
-	return falseReturn
+	// odd node policy: a constant with (and only with) ConstantPadOddNodes
+	if oddNodes < DefaultOddNodes || oddNodes > ConstantPadOddNodes ||
+		(!configurable && oddNodes != DefaultOddNodes) ||
+		(oddNodes == ConstantPadOddNodes) != (len(oddNodePad) > 0) {
+		validationErrs = append(validationErrs, "invalid odd node policy")
+	}
+	// single leaf policy
+	if singleLeaf < DefaultSingleLeaf || singleLeaf > RejectSingleLeaf ||
+		(!configurable && singleLeaf != DefaultSingleLeaf) {
+		validationErrs = append(validationErrs, "invalid single leaf policy")
+	}
+	// nothing detected: retrun nil
+	if len(validationErrs) == 0 {
+		return nil
+	}
+
+	// construct error message and return it
+	for _, valErr := range validationErrs {
+		sb.WriteString(fmt.Sprintf("%s - ", valErr))
+	}
+
+	return &ArgumentErr{sb.String()}
 }
 
-// Remove elements with nill byte content and collapse slice.
-//   - ie:
-//     [12] [nil] [34] [nil] [56] [78] => [12] [34] 56] [78]
-func (ms *MerkleService) removeNillBytes(processType int, startValue ...int) {
-	zeroStart := []int{0}
-	start := If(len(startValue) > 0, startValue[0], zeroStart[0])
-	switch processType {
-	case BinaryTree:
-		l := len(ms.Leaves)
-		found := true
-		for found {
-			found = false
-			for i := start; i < l; i++ {
-				if len(ms.Leaves[i]) == 0 {
-					ms.Leaves = append(ms.Leaves[:i], ms.Leaves[i+1:]...)
-					found = true
-					l--
-				}
-			}
-		}
+// Digest of data with the service's algorithm (and digest size).
+func (ms *MerkleService) Hash(data []byte) []byte {
+	return ms.hasher.Sum(nil, data, nil, nil)
+}
+
+// Hash left then right (preceded by the node prefix, if any), never concatenated.
+// With sorted pairs, the smaller one goes first: hashing is commutative.
+func (ms *MerkleService) hashPair(left, right []byte) []byte {
+	return ms.appendPair(nil, left, right)
+}
+
+// hashPair, appended to dst (which may overlap left or right).
+func (ms *MerkleService) appendPair(dst, left, right []byte) []byte {
+	if ms.sortedPairs && bytes.Compare(left, right) > 0 {
+		left, right = right, left
+	}
+	return ms.hasher.Sum(dst, ms.nodePrefix, left, right)
+}
+
+// Hash a leaf (preceded by the leaf prefix, if any), twice if requested.
+func (ms *MerkleService) hashLeaf(leaf []byte) []byte {
+	digest := ms.hasher.Sum(nil, ms.leafPrefix, leaf, nil)
+	if ms.doubleLeafHash {
+		return ms.hasher.Sum(digest[:0], digest, nil, nil)
+	}
+	return digest
+}
+
+// Rejected empty leaf, if any (see WithEmptyLeaves).
+func (ms *MerkleService) checkLeaves(leaves ...[]byte) error {
+	if ms.emptyLeaves != RejectEmptyLeaves {
+		return nil
+	}
+	if index := slices.IndexFunc(leaves, func(leaf []byte) bool { return len(leaf) == 0 }); index >= 0 {
+		return &EmptyLeafErr{index}
+	}
+	return nil
+}
 
+// Leaf as it enters the tree: hashed or reversed, if requested.
+// An empty leaf is the digest of the empty message, or a zero digest, as the policy says.
+func (ms *MerkleService) prepareLeaf(leaf []byte) []byte {
+	switch {
+	case len(leaf) == 0 && ms.emptyLeaves == HashEmptyLeaves:
+		return ms.hashLeaf(nil)
+	case len(leaf) == 0 && ms.emptyLeaves == ZeroDigestEmptyLeaves:
+		return make([]byte, ms.digestSize)
+	case ms.reverseByteOrder:
+		return reversedBytes(leaf)
+	case ms.leafHashing:
+		return ms.hashLeaf(leaf)
 	default:
-		ms.Leaves = slices.DeleteFunc(ms.Leaves, func(leaf []byte) bool {
-			return len(leaf) == 0
-		})
+		return leaf
 	}
 }
+
+// Root as it leaves the tree: reversed, if requested.
+func (ms *MerkleService) finalRoot(root []byte) []byte {
+	if ms.reverseByteOrder {
+		return reversedBytes(root)
+	}
+	return root
+}
+
+// Copy of b in reverse order.
+func reversedBytes(b []byte) []byte {
+	reversed := slices.Clone(b)
+	slices.Reverse(reversed)
+	return reversed
+}
+
+// Check for cancellation or expired deadline between levels.
+func checkContext(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return &ProcessTimedOutErr{ctx.Err()}
+	default:
+		return nil
+	}
+}
+
+// Ternary operator
+func If[T any](cond bool, trueReturn, falseReturn T) T {
+	if cond {
+		return trueReturn
+	}
+	return falseReturn
+}
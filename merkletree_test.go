@@ -2,11 +2,16 @@ package merkletree
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -101,6 +106,121 @@ func TestDeriveRoot(t *testing.T) {
 
 }
 
+func TestDeriveRootContext(t *testing.T) {
+	// caller's deadline is honoured (and not capped by the default timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var leaves [][]byte
+	for _, word := range strings.Split("I want proof right now", " ") {
+		leaves = append(leaves, SHA256SUM256([]byte(word)))
+	}
+
+	output, err := DeriveRootContext(ctx, leaves, "SHA256SUM256", DupeAppend)
+	if err != nil {
+		t.Fatalf("(err) got %q, wanted nil", err)
+	}
+	if !bytes.Equal(output, resultSHA256SUM256_0) {
+		t.Errorf("(out) got %q, wanted %q", hex.EncodeToString(output), hex.EncodeToString(resultSHA256SUM256_0))
+	}
+
+	// cancelled context stops the request
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	for _, processType := range []int{PassThrough, DupeAppend, BinaryTree} {
+		_, err = DeriveRootContext(ctx, tenThousandElements0, "SHA256SUM256", processType)
+		var timedOut *ProcessTimedOutErr
+		if !errors.As(err, &timedOut) || !errors.Is(err, context.Canceled) {
+			t.Errorf("(err) got %v, wanted %v", err, &ProcessTimedOutErr{context.Canceled})
+		}
+	}
+
+	// leaf hashing runs under the request's context too: it stops before the leaves are hashed
+	ms, _ := New(WithAlgorithm("SHA256SUM256"), WithLeafHashing(true), WithWorkers(1))
+	job, _ := ms.newJob(tenThousandElements0)
+	err = job.prepareLeaves(ctx)
+	var timedOut *ProcessTimedOutErr
+	if !errors.As(err, &timedOut) || !errors.Is(err, context.Canceled) {
+		t.Errorf("(err) got %v, wanted %v", err, &ProcessTimedOutErr{context.Canceled})
+	}
+	if !bytes.Equal(job.Leaves[0], tenThousandElements0[0]) {
+		t.Errorf("(leaves) hashed under a cancelled context")
+	}
+	if _, err = ms.DeriveContext(ctx, tenThousandElements0); !errors.As(err, &timedOut) {
+		t.Errorf("(err) got %v, wanted %v", err, &ProcessTimedOutErr{context.Canceled})
+	}
+}
+
+func TestNew(t *testing.T) {
+	// invalid configuration is rejected once, at construction
+	for _, opts := range [][]Option{
+		{WithAlgorithm("NOPE")},
+		{WithAlgorithm("")},
+		{WithAlgorithm(""), WithProcessType(Bitcoin)},
+		{WithProcessType(-1)},
+		{WithProcessType(99)},
+	} {
+		var argErr *ArgumentErr
+		if _, err := New(opts...); !errors.As(err, &argErr) {
+			t.Errorf("(err) got %v, wanted *ArgumentErr", err)
+		}
+	}
+
+	if _, err := DeriveRoot(makeIndexedLeaves(3), "", PassThrough); err == nil {
+		t.Errorf("(empty algorithm err) got nil, wanted an argument error")
+	}
+
+	// no algorithm requested: the process type's own, DefaultAlgorithm otherwise
+	for processType, algorithm := range map[int]string{PassThrough: DefaultAlgorithm, Bitcoin: "SHA256D"} {
+		if ms, err := New(WithProcessType(processType)); err != nil || ms.HashTypeID != algorithm {
+			t.Errorf("process %d: (default algorithm) got %v (%v), wanted %s", processType, ms, err, algorithm)
+		}
+	}
+
+	ms, err := New(WithAlgorithm("sha256sum256"), WithProcessType(BinaryTree), WithLeafHashing(true))
+	if err != nil {
+		t.Fatalf("(err) got %q, wanted nil", err)
+	}
+
+	// service is reusable: same input, same root
+	words := [][]byte{}
+	for _, word := range strings.Split("I want proof right now", " ") {
+		words = append(words, []byte(word))
+	}
+	for i := 0; i < 3; i++ {
+		output, err := ms.Derive(words)
+		if err != nil {
+			t.Fatalf("(err) got %q, wanted nil", err)
+		}
+		if !bytes.Equal(output, resultSHA256SUM256_2) {
+			t.Errorf("(out) got %q, wanted %q", hex.EncodeToString(output), hex.EncodeToString(resultSHA256SUM256_2))
+		}
+	}
+
+	// empty data
+	var argErr *ArgumentErr
+	if _, err := ms.Derive(nil); !errors.As(err, &argErr) {
+		t.Errorf("(err) got %v, wanted *ArgumentErr", err)
+	}
+
+	// configuration changed since New: rejected, not run
+	for name, change := range map[string]func(ms *MerkleService){
+		"algorithm":    func(ms *MerkleService) { ms.HashTypeID = "SHA512" },
+		"process type": func(ms *MerkleService) { ms.ProcessType = 99 },
+		"registry":     func(ms *MerkleService) { delete(ms.ProcessTypeRegistry, ms.ProcessType) },
+	} {
+		changed, _ := New(WithAlgorithm("SHA256SUM256"))
+		change(changed)
+		if _, err := changed.Derive(words); !errors.As(err, &argErr) {
+			t.Errorf("%s: (err) got %v, wanted *ArgumentErr", name, err)
+		}
+		if _, err := changed.GenerateProof(words, 0); !errors.As(err, &argErr) {
+			t.Errorf("%s: (proof err) got %v, wanted *ArgumentErr", name, err)
+		}
+	}
+}
+
 func BenchmarkDeriveRoot10000LeavesSHA256SUM256DupAppend(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		DeriveRoot(tenThousandElements0, "SHA256SUM256", DupeAppend)
@@ -118,3 +238,449 @@ func BenchmarkDeriveRoot10000LeavesSHA256SUM256BinaryTree(b *testing.B) {
 		DeriveRoot(tenThousandElements2, "SHA256SUM256", BinaryTree)
 	}
 }
+
+func BenchmarkDeriveRoot10000LeavesSHA256SUM256PassThroughParallel(b *testing.B) {
+	ms, _ := New(WithProcessType(PassThrough), WithWorkers(runtime.NumCPU()), WithTimeout(0))
+	for i := 0; i < b.N; i++ {
+		ms.Derive(tenThousandElements1)
+	}
+}
+
+func TestParallelLevels(t *testing.T) {
+	for _, processType := range []int{PassThrough, DupeAppend, BinaryTree, RFC6962, StandardMerkleTree, Bitcoin, Monero} {
+		for _, count := range []int{4099, 10000} {
+			leaves := makeIndexedLeaves(count)
+			sequential, _ := New(WithProcessType(processType), WithLeafHashing(true), WithTimeout(0))
+			parallel, _ := New(WithProcessType(processType), WithLeafHashing(true), WithTimeout(0), WithWorkers(8))
+
+			expected, err := sequential.Derive(leaves)
+			if err != nil {
+				t.Fatalf("process %d, count %d: (err) got %q, wanted nil", processType, count, err)
+			}
+			output, err := parallel.Derive(leaves)
+			if err != nil {
+				t.Fatalf("process %d, count %d: (parallel err) got %q, wanted nil", processType, count, err)
+			}
+			if !bytes.Equal(output, expected) {
+				t.Errorf("process %d, count %d: (out) got %x, wanted %x", processType, count, output, expected)
+			}
+
+			proof, _ := parallel.GenerateProof(leaves, count-3)
+			if ok, err := sequential.VerifyProof(leaves[count-3], proof, expected); !ok || err != nil {
+				t.Errorf("process %d, count %d: (verify) got %v (%v), wanted true", processType, count, ok, err)
+			}
+		}
+	}
+}
+
+func TestInputIsNotModified(t *testing.T) {
+	for _, processType := range []int{PassThrough, DupeAppend, BinaryTree} {
+		// spare capacity everywhere: in the slice and in every leaf
+		hashes := make([][]byte, 0, 16)
+		for _, leaf := range makeIndexedLeaves(7) {
+			hashes = append(hashes, append(make([]byte, 0, 2*len(leaf)), leaf...))
+		}
+		spare := hashes[:cap(hashes)]
+		snapshot := make([][]byte, len(spare))
+		for i, leaf := range spare {
+			snapshot[i] = bytes.Clone(leaf[:cap(leaf)])
+		}
+
+		ms, _ := New(WithProcessType(processType))
+		if _, err := ms.Derive(hashes); err != nil {
+			t.Fatalf("(err) got %q, wanted nil", err)
+		}
+		if _, err := ms.GenerateProof(hashes, 6); err != nil {
+			t.Fatalf("(err) got %q, wanted nil", err)
+		}
+		if _, err := ms.BuildTree(hashes); err != nil {
+			t.Fatalf("(err) got %q, wanted nil", err)
+		}
+		// (proof nodes are the caller's to write to)
+		for m := 1; processType == PassThrough && m <= 7; m++ {
+			proof, err := ms.ConsistencyProof(hashes, m, 7)
+			if err != nil {
+				t.Fatalf("(err) got %q, wanted nil", err)
+			}
+			for _, node := range proof {
+				for i := range node {
+					node[i] = 0xff
+				}
+			}
+		}
+
+		if len(hashes) != 7 {
+			t.Errorf("process %d: (len) got %d, wanted 7", processType, len(hashes))
+		}
+		for i, leaf := range spare {
+			if !bytes.Equal(leaf[:cap(leaf)], snapshot[i]) {
+				t.Errorf("process %d: element %d modified", processType, i)
+			}
+		}
+	}
+}
+
+func TestDomainSeparation(t *testing.T) {
+	rfcPrefixes := WithDomainSeparation([]byte{RFC6962LeafPrefix}, []byte{RFC6962NodePrefix})
+
+	// PassThrough with the RFC 6962 prefixes is RFC 6962
+	ms, err := New(WithProcessType(PassThrough), rfcPrefixes)
+	if err != nil {
+		t.Fatalf("(err) got %q, wanted nil", err)
+	}
+	expected, _ := DeriveRoot(makeIndexedLeaves(11), "SHA256SUM256", RFC6962)
+	if output, _ := ms.Derive(makeIndexedLeaves(11)); !bytes.Equal(output, expected) {
+		t.Errorf("(out) got %q, wanted %q", hex.EncodeToString(output), hex.EncodeToString(expected))
+	}
+
+	for _, processType := range []int{PassThrough, DupeAppend, BinaryTree} {
+		leaves := makeIndexedLeaves(8)
+		level1 := [][]byte{}
+		for i := 0; i < len(leaves); i += 2 {
+			level1 = append(level1, SHA256SUM256(append(bytes.Clone(leaves[i]), leaves[i+1]...)))
+		}
+
+		// without: the first level passed off as leaves gives the same root
+		plain, _ := DeriveRoot(leaves, "SHA256SUM256", processType)
+		forged, _ := DeriveRoot(level1, "SHA256SUM256", processType)
+		if !bytes.Equal(plain, forged) {
+			t.Fatalf("process %d: second preimage expected without domain separation", processType)
+		}
+
+		ms, _ := New(WithProcessType(processType), WithDomainSeparation([]byte("leaf:"), []byte("node:")))
+		separated, _ := ms.Derive(leaves)
+		forged, _ = ms.Derive(level1)
+		if bytes.Equal(separated, forged) || bytes.Equal(separated, plain) {
+			t.Errorf("process %d: domain separation not applied", processType)
+		}
+
+		// proofs still verify
+		proof, _ := ms.GenerateProof(leaves, 5)
+		if ok, err := ms.VerifyProof(leaves[5], proof, separated); !ok || err != nil {
+			t.Errorf("process %d: (verify) got %v (%v), wanted true", processType, ok, err)
+		}
+	}
+
+	for _, prefixes := range [][2][]byte{{nil, nil}, {{0x00}, {0x00}}, {{0x00}, {0x00, 0x01}}} {
+		var argErr *ArgumentErr
+		if _, err := New(WithDomainSeparation(prefixes[0], prefixes[1])); !errors.As(err, &argErr) {
+			t.Errorf("prefixes %v: (err) got %v, wanted *ArgumentErr", prefixes, err)
+		}
+	}
+
+	// RFC 6962: its own prefixes only
+	for _, test := range []struct {
+		leafPrefix, nodePrefix []byte
+		valid                  bool
+	}{
+		{[]byte{RFC6962LeafPrefix}, []byte{RFC6962NodePrefix}, true},
+		{[]byte("leaf:"), []byte("node:"), false},
+		{[]byte{RFC6962NodePrefix}, []byte{RFC6962LeafPrefix}, false},
+	} {
+		_, err := New(WithProcessType(RFC6962), WithDomainSeparation(test.leafPrefix, test.nodePrefix))
+		if (err == nil) != test.valid {
+			t.Errorf("RFC6962, prefixes %q %q: (err) got %v, wanted valid %v", test.leafPrefix, test.nodePrefix, err, test.valid)
+		}
+	}
+}
+
+func TestDetectMutation(t *testing.T) {
+	leaves := makeIndexedLeaves(6)
+	testCases := []struct {
+		name    string
+		leaves  [][]byte
+		mutated bool
+	}{
+		{"odd", leaves[:3], false},
+		{"even", leaves[:4], false},
+		{"duplicated leaf", [][]byte{leaves[0], leaves[1], leaves[2], leaves[2]}, true},
+		{"duplicated pair", [][]byte{leaves[0], leaves[1], leaves[2], leaves[3], leaves[4], leaves[5], leaves[4], leaves[5]}, true},
+		{"duplicated inner leaf", [][]byte{leaves[0], leaves[0], leaves[1]}, false},
+	}
+
+	for _, processType := range []int{DupeAppend, Bitcoin} {
+		for _, tc := range testCases {
+			mutated, err := DetectMutation(tc.leaves, If(processType == Bitcoin, "SHA256D", "SHA256SUM256"), processType)
+			if err != nil {
+				t.Fatalf("process %d, %s: (err) got %q, wanted nil", processType, tc.name, err)
+			}
+			if mutated != tc.mutated {
+				t.Errorf("process %d, %s: (mutated) got %v, wanted %v", processType, tc.name, mutated, tc.mutated)
+			}
+
+			ms, _ := New(WithProcessType(processType), WithStrictMutation(true))
+			_, err = ms.Derive(tc.leaves)
+			var mutatedErr *MutatedTreeErr
+			if errors.As(err, &mutatedErr) != tc.mutated {
+				t.Errorf("process %d, %s: (strict err) got %v, wanted mutated %v", processType, tc.name, err, tc.mutated)
+			}
+		}
+	}
+
+	// the collision being detected
+	original, _ := DeriveRoot(leaves[:3], "SHA256SUM256", DupeAppend)
+	mutated, _ := DeriveRoot([][]byte{leaves[0], leaves[1], leaves[2], leaves[2]}, "SHA256SUM256", DupeAppend)
+	if !bytes.Equal(original, mutated) {
+		t.Errorf("(out) got %x, wanted %x", mutated, original)
+	}
+
+	if _, err := DetectMutation(leaves, "SHA256SUM256", PassThrough); err == nil {
+		t.Errorf("(err) got nil, wanted unsupported process type")
+	}
+}
+
+func TestEmptyLeaves(t *testing.T) {
+	leaves := makeIndexedLeaves(5)
+	withEmpty := [][]byte{leaves[0], leaves[1], {}, leaves[3], leaves[4]}
+	substituted := func(leaf []byte) [][]byte {
+		return [][]byte{leaves[0], leaves[1], leaf, leaves[3], leaves[4]}
+	}
+
+	for _, processType := range []int{PassThrough, DupeAppend, BinaryTree} {
+		testCases := []struct {
+			name   string
+			opts   []Option
+			leaves [][]byte // same root as withEmpty, nil: rejected
+		}{
+			{"default", nil, nil},
+			{"reject", []Option{WithEmptyLeaves(RejectEmptyLeaves)}, nil},
+			{"hash", []Option{WithEmptyLeaves(HashEmptyLeaves)}, substituted(SHA256SUM256(nil))},
+			{"zero digest", []Option{WithEmptyLeaves(ZeroDigestEmptyLeaves)}, substituted(make([]byte, 32))},
+		}
+
+		for _, tc := range testCases {
+			ms, err := New(append(tc.opts, WithProcessType(processType))...)
+			if err != nil {
+				t.Fatalf("process %d, %s: (err) got %q, wanted nil", processType, tc.name, err)
+			}
+			root, err := ms.Derive(withEmpty)
+
+			var emptyErr *EmptyLeafErr
+			if tc.leaves == nil {
+				if !errors.As(err, &emptyErr) || err.Error() != "empty leaf at index 2" {
+					t.Errorf("process %d, %s: (err) got %v, wanted EmptyLeafErr at index 2", processType, tc.name, err)
+				}
+				continue
+			}
+			want, _ := DeriveRoot(tc.leaves, "SHA256SUM256", processType)
+			if err != nil || !bytes.Equal(root, want) {
+				t.Errorf("process %d, %s: (out) got %x (%v), wanted %x", processType, tc.name, root, err, want)
+			}
+		}
+	}
+
+	// leaves hashed (RFC 6962): by default an empty leaf is hashed as the empty message,
+	// a zero digest is not hashed
+	hashed, err := DeriveRoot(withEmpty, "SHA256SUM256", RFC6962)
+	explicit, _ := New(WithProcessType(RFC6962), WithEmptyLeaves(HashEmptyLeaves))
+	if want, _ := explicit.Derive(withEmpty); err != nil || !bytes.Equal(hashed, want) {
+		t.Errorf("(rfc 6962) got %x (%v), wanted %x", hashed, err, want)
+	}
+	zeroed, _ := New(WithProcessType(RFC6962), WithEmptyLeaves(ZeroDigestEmptyLeaves))
+	if root, _ := zeroed.Derive(withEmpty); bytes.Equal(root, hashed) {
+		t.Errorf("(rfc 6962 zero digest) got the hashed empty leaf's root")
+	}
+
+	// never dropped: the empty leaf still takes its place in the tree
+	ms, _ := New(WithEmptyLeaves(HashEmptyLeaves))
+	kept, _ := ms.Derive(withEmpty)
+	dropped, _ := ms.Derive(slices.Delete(slices.Clone(withEmpty), 2, 3))
+	if bytes.Equal(kept, dropped) {
+		t.Errorf("(out) empty leaf dropped")
+	}
+
+	// proofs and builders follow the policy
+	proof, _ := ms.GenerateProof(withEmpty, 2)
+	if ok, err := ms.VerifyProof(nil, proof, kept); !ok || err != nil {
+		t.Errorf("(verify) got %v (%v), wanted true", ok, err)
+	}
+	builder := ms.NewBuilder()
+	for _, leaf := range withEmpty {
+		builder.Append(leaf)
+	}
+	if root, err := builder.Root(); err != nil || !bytes.Equal(root, kept) {
+		t.Errorf("(builder) got %x (%v), wanted %x", root, err, kept)
+	}
+
+	rejecting, _ := New()
+	var emptyErr *EmptyLeafErr
+	if _, err := rejecting.VerifyProof(nil, proof, kept); !errors.As(err, &emptyErr) {
+		t.Errorf("(verify err) got %v, wanted EmptyLeafErr", err)
+	}
+	builder = rejecting.NewBuilder()
+	for _, leaf := range withEmpty {
+		builder.Append(leaf)
+	}
+	if _, err := builder.Root(); !errors.As(err, &emptyErr) || err.Error() != "empty leaf at index 2" {
+		t.Errorf("(builder err) got %v, wanted EmptyLeafErr at index 2", err)
+	}
+
+	// BLAKE3 chunk trees: an empty input is a single empty chunk
+	if _, err := New(WithProcessType(BLAKE3Tree), WithEmptyLeaves(RejectEmptyLeaves)); err == nil {
+		t.Errorf("(blake3 err) got nil, wanted an argument error")
+	}
+	if root, err := DeriveRoot([][]byte{{}}, "BLAKE3", BLAKE3Tree); err != nil || !bytes.Equal(root, BLAKE3(nil)) {
+		t.Errorf("(blake3) got %x (%v), wanted %x", root, err, BLAKE3(nil))
+	}
+}
+
+// root of leaves paired level by level, an odd node promoted (pad nil) or hashed with its pad
+func referencePaddedRoot(leaves [][]byte, pad func(node []byte) []byte) []byte {
+	level := leaves
+	for len(level) > 1 {
+		next := [][]byte{}
+		for i := 0; i < len(level); i += 2 {
+			switch {
+			case i+1 < len(level):
+				next = append(next, SHA256SUM256(append(bytes.Clone(level[i]), level[i+1]...)))
+			case pad == nil:
+				next = append(next, level[i])
+			default:
+				next = append(next, SHA256SUM256(append(bytes.Clone(level[i]), pad(level[i])...)))
+			}
+		}
+		level = next
+	}
+	return level[0]
+}
+
+func TestOddNodePolicies(t *testing.T) {
+	constant := []byte("odd node")
+	testCases := []struct {
+		name string
+		opts []Option
+		pad  func(node []byte) []byte
+	}{
+		{"promote", []Option{WithOddNodes(PromoteOddNodes)}, nil},
+		{"duplicate", []Option{WithOddNodes(DuplicateOddNodes)}, func(node []byte) []byte { return node }},
+		{"zero pad", []Option{WithOddNodes(ZeroPadOddNodes)}, func([]byte) []byte { return make([]byte, 32) }},
+		{"constant pad", []Option{WithOddNodeConstant(constant)}, func([]byte) []byte { return constant }},
+	}
+
+	for _, processType := range []int{PassThrough, DupeAppend} {
+		for _, tc := range testCases {
+			ms, err := New(append(tc.opts, WithProcessType(processType))...)
+			if err != nil {
+				t.Fatalf("process %d, %s: (err) got %q, wanted nil", processType, tc.name, err)
+			}
+
+			for count := 2; count <= 17; count++ {
+				leaves := makeIndexedLeaves(count)
+				want := referencePaddedRoot(leaves, tc.pad)
+				if root, err := ms.Derive(leaves); err != nil || !bytes.Equal(root, want) {
+					t.Errorf("process %d, %s, %d leaves: (out) got %x (%v), wanted %x", processType, tc.name, count, root, err, want)
+				}
+
+				builder := ms.NewBuilder()
+				for _, leaf := range leaves {
+					builder.Append(leaf)
+				}
+				if root, err := builder.Root(); err != nil || !bytes.Equal(root, want) {
+					t.Errorf("process %d, %s, %d leaves: (builder) got %x (%v), wanted %x", processType, tc.name, count, root, err, want)
+				}
+
+				for leafIndex := range leaves {
+					proof, err := ms.GenerateProof(leaves, leafIndex)
+					if err != nil {
+						t.Fatalf("process %d, %s, %d leaves: (proof err) got %q, wanted nil", processType, tc.name, count, err)
+					}
+					if ok, err := ms.VerifyProof(leaves[leafIndex], proof, want); !ok || err != nil {
+						t.Errorf("process %d, %s, %d leaves, leaf %d: (verify) got %v (%v), wanted true", processType, tc.name, count, leafIndex, ok, err)
+					}
+				}
+			}
+		}
+	}
+
+	// the defaults: the process types as they were
+	for processType, policy := range map[int]OddNodePolicy{PassThrough: PromoteOddNodes, DupeAppend: DuplicateOddNodes, Bitcoin: DuplicateOddNodes} {
+		if ms, _ := New(WithProcessType(processType)); ms.oddNodes != policy {
+			t.Errorf("process %d: (default) got %d, wanted %d", processType, ms.oddNodes, policy)
+		}
+	}
+
+	for _, opts := range [][]Option{
+		{WithProcessType(RFC6962), WithOddNodes(DuplicateOddNodes)},
+		{WithProcessType(Bitcoin), WithOddNodes(PromoteOddNodes)},
+		{WithOddNodes(ConstantPadOddNodes)},
+		{WithOddNodeConstant([]byte{})},
+		{WithOddNodeConstant(constant), WithOddNodes(ZeroPadOddNodes)},
+		{WithOddNodes(OddNodePolicy(9))},
+	} {
+		if _, err := New(opts...); err == nil {
+			t.Errorf("(err) got nil, wanted an argument error")
+		}
+	}
+
+	// consistency proofs need the RFC 6962 shape
+	ms, _ := New(WithOddNodes(ZeroPadOddNodes))
+	if _, err := ms.ConsistencyProof(makeIndexedLeaves(7), 3, 7); err == nil {
+		t.Errorf("(consistency err) got nil, wanted an argument error")
+	}
+}
+
+func TestSingleLeafPolicies(t *testing.T) {
+	leaf := makeIndexedLeaves(1)[0]
+	hashed := SHA256SUM256(leaf)
+	duplicated := SHA256SUM256(append(bytes.Clone(leaf), leaf...))
+	zeroPadded := SHA256SUM256(append(bytes.Clone(leaf), make([]byte, 32)...))
+
+	testCases := []struct {
+		name        string
+		processType int
+		opts        []Option
+		root        []byte // nil: rejected
+	}{
+		{"pass through default", PassThrough, nil, leaf},
+		{"dupe append default", DupeAppend, nil, duplicated},
+		{"binary tree default", BinaryTree, nil, leaf},
+		{"as root", DupeAppend, []Option{WithSingleLeaf(SingleLeafAsRoot)}, leaf},
+		{"hash", PassThrough, []Option{WithSingleLeaf(HashSingleLeaf)}, hashed},
+		{"hash binary tree", BinaryTree, []Option{WithSingleLeaf(HashSingleLeaf)}, hashed},
+		{"pair promoted", PassThrough, []Option{WithSingleLeaf(PairSingleLeaf)}, leaf},
+		{"pair duplicated", BinaryTree, []Option{WithSingleLeaf(PairSingleLeaf), WithOddNodes(DuplicateOddNodes)}, duplicated},
+		{"pair zero padded", DupeAppend, []Option{WithOddNodes(ZeroPadOddNodes)}, zeroPadded},
+		{"reject", BinaryTree, []Option{WithSingleLeaf(RejectSingleLeaf)}, nil},
+	}
+
+	for _, tc := range testCases {
+		ms, err := New(append(tc.opts, WithProcessType(tc.processType))...)
+		if err != nil {
+			t.Fatalf("%s: (err) got %q, wanted nil", tc.name, err)
+		}
+
+		root, err := ms.Derive([][]byte{leaf})
+		builder := ms.NewBuilder()
+		builder.Append(leaf)
+		builderRoot, builderErr := builder.Root()
+
+		if tc.root == nil {
+			var argErr *ArgumentErr
+			if !errors.As(err, &argErr) || !errors.As(builderErr, &argErr) {
+				t.Errorf("%s: (err) got %v, %v, wanted *ArgumentErr", tc.name, err, builderErr)
+			}
+			continue
+		}
+		if err != nil || !bytes.Equal(root, tc.root) {
+			t.Errorf("%s: (out) got %x (%v), wanted %x", tc.name, root, err, tc.root)
+		}
+		if builderErr != nil || !bytes.Equal(builderRoot, tc.root) {
+			t.Errorf("%s: (builder) got %x (%v), wanted %x", tc.name, builderRoot, builderErr, tc.root)
+		}
+
+		proof, err := ms.GenerateProof([][]byte{leaf}, 0)
+		if err != nil {
+			t.Fatalf("%s: (proof err) got %q, wanted nil", tc.name, err)
+		}
+		if ok, err := ms.VerifyProof(leaf, proof, tc.root); !ok || err != nil {
+			t.Errorf("%s: (verify) got %v (%v), wanted true", tc.name, ok, err)
+		}
+	}
+
+	// the standardized process types keep their own
+	for _, processType := range []int{RFC6962, Bitcoin, Monero, StandardMerkleTree} {
+		if _, err := New(WithProcessType(processType), WithSingleLeaf(HashSingleLeaf)); err == nil {
+			t.Errorf("process %d: (err) got nil, wanted an argument error", processType)
+		}
+	}
+}
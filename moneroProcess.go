@@ -0,0 +1,31 @@
+package merkletree
+
+//
+// Monero tree_hash process type (src/crypto/tree-hash.c).
+//
+//	- Data: the block's transaction hashes (miner transaction first).
+//	- Nodes: Keccak-256 (KECCAK256, cn_fast_hash) of left || right.
+//	- The count is first reduced to a power of two by pairing the trailing nodes
+//	  (the BinaryTree split), then pairs are hashed level by level.
+//	- A single transaction hash is the root itself.
+//
+
+import (
+	"context"
+)
+
+func (ms *MerkleService) processMoneroRequest(ctx context.Context) error {
+	const ThisProcess = 6
+	contextProcessType := ctx.Value(contextKeyRequestID)
+	if contextProcessType != processTypes[ThisProcess] {
+		return &InvalidContextProcessTypeErr{contextProcessType.(string)}
+	}
+
+	// Single transaction: it is the root.
+	if len(ms.Leaves) == 1 {
+		ms.ProcessResult = ms.Leaves[0]
+		return nil
+	}
+
+	return ms.binaryTree(ctx)
+}
@@ -0,0 +1,161 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+// Mainnet blocks: the tree hash is checked through the block id,
+// keccak256(varint(len(blob)) || blob), blob: header || tree hash || varint(tx count).
+var xmrBlocks = []struct {
+	height       int
+	majorVersion uint64
+	minorVersion uint64
+	timestamp    uint64
+	prevID       string
+	nonce        uint32
+	txHashes     []string // miner transaction first
+	id           string
+}{
+	{912345, 1, 2, 1452793716, "b61c58b2e0be53fad5ef9d9731a55e8a81d972b8d90ed07c04fd37ca6403ff78", 1646,
+		[]string{"c7da3965f25c19b8eb7dd8db48dcd4e7c885e2491db77e289f0609bf8e08ec30"},
+		"e22cf75f39ae720e8b71b3d120a5ac03f0db50bba6379e2850975b4859190bc6"},
+	{2751506, 16, 16, 1667941829, "b27bdecfc6cd0a46172d136c08831cf67660377ba992332363228b1b722781e7", 4110909056,
+		[]string{"e49b854c5f339d7410a77f2a137281d8042a0ffc7ef9ab24cd670b67139b24cd"},
+		"43bd1f2b6556dcafa413d8372974af59e4e8f37dbf74dc6b2a9b7212d0577428"},
+}
+
+func TestMoneroBlocks(t *testing.T) {
+	for _, block := range xmrBlocks {
+		hashes := make([][]byte, len(block.txHashes))
+		for i, txHash := range block.txHashes {
+			hashes[i], _ = hex.DecodeString(txHash)
+		}
+
+		root, err := DeriveRoot(hashes, "KECCAK256", Monero)
+		if err != nil {
+			t.Fatalf("block %d: (err) got %q, wanted nil", block.height, err)
+		}
+
+		prevID, _ := hex.DecodeString(block.prevID)
+		blob := binary.AppendUvarint(nil, block.majorVersion)
+		blob = binary.AppendUvarint(blob, block.minorVersion)
+		blob = binary.AppendUvarint(blob, block.timestamp)
+		blob = append(blob, prevID...)
+		blob = binary.LittleEndian.AppendUint32(blob, block.nonce)
+		blob = append(blob, root...)
+		blob = binary.AppendUvarint(blob, uint64(len(hashes)))
+
+		if id := hex.EncodeToString(KECCAK256(append(binary.AppendUvarint(nil, uint64(len(blob))), blob...))); id != block.id {
+			t.Errorf("block %d: (id) got %s, wanted %s", block.height, id, block.id)
+		}
+	}
+}
+
+// Tail pairing, spelled out: the trailing 2*(count-cnt) hashes are paired first,
+// the leading ones carried over, cnt being the largest power of two below count.
+func TestMoneroTailPairing(t *testing.T) {
+	h := makeIndexedLeaves(7)
+	for i := range h {
+		h[i] = KECCAK256(h[i])
+	}
+	pair := func(a, b []byte) []byte { return KECCAK256(append(bytes.Clone(a), b...)) }
+
+	tests := []struct {
+		count    int
+		expected []byte
+	}{
+		// cnt 2: (h0, (h1 h2))
+		{3, pair(h[0], pair(h[1], h[2]))},
+		// cnt 4: (h0 h1), (h2, (h3 h4))
+		{5, pair(pair(h[0], h[1]), pair(h[2], pair(h[3], h[4])))},
+		// cnt 4: (h0, (h1 h2)), ((h3 h4), (h5 h6))
+		{7, pair(pair(h[0], pair(h[1], h[2])), pair(pair(h[3], h[4]), pair(h[5], h[6])))},
+	}
+
+	for _, test := range tests {
+		output, err := DeriveRoot(h[:test.count], "KECCAK256", Monero)
+		if err != nil {
+			t.Fatalf("count %d: (err) got %q, wanted nil", test.count, err)
+		}
+		if !bytes.Equal(output, test.expected) {
+			t.Errorf("count %d: (out) got %x, wanted %x", test.count, output, test.expected)
+		}
+	}
+}
+
+// Port of Monero's tree_hash (src/crypto/tree-hash.c), as the reference.
+func moneroTreeHash(hashes [][]byte) []byte {
+	count := len(hashes)
+	switch count {
+	case 1:
+		return hashes[0]
+	case 2:
+		return KECCAK256(append(bytes.Clone(hashes[0]), hashes[1]...))
+	}
+
+	// tree_hash_cnt: largest power of two smaller than count
+	cnt := 2
+	for cnt < count {
+		cnt <<= 1
+	}
+	cnt >>= 1
+
+	ints := make([][]byte, cnt)
+	copy(ints, hashes[:2*cnt-count])
+	i := 2*cnt - count
+	for j := 2*cnt - count; j < cnt; i, j = i+2, j+1 {
+		ints[j] = KECCAK256(append(bytes.Clone(hashes[i]), hashes[i+1]...))
+	}
+
+	for cnt > 2 {
+		cnt >>= 1
+		for i, j := 0, 0; j < cnt; i, j = i+2, j+1 {
+			ints[j] = KECCAK256(append(bytes.Clone(ints[i]), ints[i+1]...))
+		}
+	}
+
+	return KECCAK256(append(bytes.Clone(ints[0]), ints[1]...))
+}
+
+func TestMoneroTreeHash(t *testing.T) {
+	for count := 1; count <= 33; count++ {
+		hashes := makeIndexedLeaves(count)
+		for i := range hashes {
+			hashes[i] = KECCAK256(hashes[i])
+		}
+		expected := moneroTreeHash(hashes)
+
+		output, err := DeriveRoot(hashes, "KECCAK256", Monero)
+		if err != nil {
+			t.Fatalf("count %d: (err) got %q, wanted nil", count, err)
+		}
+		if !bytes.Equal(output, expected) {
+			t.Errorf("count %d: (out) got %x, wanted %x", count, output, expected)
+		}
+
+		builder, _ := NewBuilder("KECCAK256", Monero)
+		for _, hash := range hashes {
+			builder.Append(hash)
+		}
+		if built, _ := builder.Root(); !bytes.Equal(built, expected) {
+			t.Errorf("count %d: (builder) got %x, wanted %x", count, built, expected)
+		}
+
+		for i := range hashes {
+			proof, err := GenerateProof(hashes, i, "KECCAK256", Monero)
+			if err != nil {
+				t.Fatalf("count %d, leaf %d: (proof err) got %q, wanted nil", count, i, err)
+			}
+			if ok, err := VerifyProof(hashes[i], proof, expected, "KECCAK256", Monero); !ok || err != nil {
+				t.Errorf("count %d, leaf %d: (verify) got %v (%v), wanted true", count, i, ok, err)
+			}
+		}
+	}
+
+	if _, err := New(WithProcessType(Monero), WithAlgorithm("SHA256SUM256")); err == nil {
+		t.Errorf("(err) got nil, wanted an algorithm mismatch error")
+	}
+}
@@ -0,0 +1,148 @@
+package merkletree
+
+//
+// Functional options for the MerkleService constructor (New).
+//
+// ie:
+//
+//	ms, err := merkletree.New(
+//		merkletree.WithAlgorithm("SHA256SUM256"),
+//		merkletree.WithProcessType(merkletree.BinaryTree),
+//	)
+//
+
+import (
+	"time"
+)
+
+// Option configures a MerkleService.
+type Option func(*MerkleService)
+
+// Hash algorithm to use (see AlgorithmRegistry).
+// Default (option not given): the process type's algorithm if it is bound to one,
+// DefaultAlgorithm otherwise. An empty algorithm is an unknown one.
+func WithAlgorithm(algorithm string) Option {
+	return func(ms *MerkleService) {
+		ms.HashTypeID = algorithm
+		ms.algorithmSet = true
+	}
+}
+
+// Process type to use (PassThrough, DupeAppend, BinaryTree, RFC6962, StandardMerkleTree, Bitcoin,
+// Monero, BLAKE3Tree, or one returned by RegisterProcessType). Default: PassThrough.
+func WithProcessType(processType int) Option {
+	return func(ms *MerkleService) {
+		ms.ProcessType = processType
+	}
+}
+
+// Maximum duration of a request. Default: ProcessTimeoutMilliSecs.
+// A zero value imposes no timeout; the caller's context still applies.
+func WithTimeout(timeout time.Duration) Option {
+	return func(ms *MerkleService) {
+		ms.timeout = timeout
+	}
+}
+
+// Hash each level's pairs (and the leaves) with up to workers goroutines, ie: runtime.NumCPU().
+// A level is only sharded when every worker gets at least ParallelMinPairs pairs.
+// Roots are identical to the sequential ones. Default: 0, sequential.
+// Algorithms registered with RegisterAlgorithm must then be safe for concurrent use.
+func WithWorkers(workers int) Option {
+	return func(ms *MerkleService) {
+		ms.workers = workers
+	}
+}
+
+// Hash all elements of the first branch (the leaves) with the algorithm before building the tree.
+func WithLeafHashing(hashLeaves bool) Option {
+	return func(ms *MerkleService) {
+		ms.leafHashing = hashLeaves
+	}
+}
+
+// Hash leaves and nodes with distinct prefixes: hash(leafPrefix || leaf), hash(nodePrefix || left || right).
+// Protects against second-preimage attacks (an interior node passed off as a leaf).
+// Implies leaf hashing. Neither prefix may be a prefix of the other.
+// ie: RFC 6962 prefixes: WithDomainSeparation([]byte{RFC6962LeafPrefix}, []byte{RFC6962NodePrefix})
+// The RFC6962 process type always uses these: other prefixes are an error.
+func WithDomainSeparation(leafPrefix, nodePrefix []byte) Option {
+	return func(ms *MerkleService) {
+		ms.leafPrefix = append([]byte{}, leafPrefix...)
+		ms.nodePrefix = append([]byte{}, nodePrefix...)
+	}
+}
+
+// Reject (MutatedTreeErr) leaves whose tree has a duplicated trailing pair: its root
+// collides with the root of the leaves without it (CVE-2012-2459).
+// Applies to the DupeAppend and Bitcoin process types, see DetectMutation.
+func WithStrictMutation(strict bool) Option {
+	return func(ms *MerkleService) {
+		ms.strictMutation = strict
+	}
+}
+
+// Digest size in bytes of an XOF algorithm (SHAKE128, SHAKE256, CSHAKE128, CSHAKE256).
+// Default: twice the security strength (32 bytes for the 128 variants, 64 for the 256 ones).
+// Fixed size algorithms do not accept one.
+func WithDigestSize(size int) Option {
+	return func(ms *MerkleService) {
+		ms.digestSize = size
+	}
+}
+
+// cSHAKE customization string (CSHAKE128, CSHAKE256): trees with different
+// customizations have unrelated nodes.
+func WithCustomization(customization []byte) Option {
+	return func(ms *MerkleService) {
+		ms.customization = append([]byte{}, customization...)
+	}
+}
+
+// What an empty (zero length) leaf stands for: RejectEmptyLeaves (EmptyLeafErr), HashEmptyLeaves
+// (the digest of the empty message) or ZeroDigestEmptyLeaves (DigestSize zero bytes).
+// Default: hashed when leaves are hashed, rejected otherwise.
+// BLAKE3Tree has its own: an empty input is a single empty chunk.
+func WithEmptyLeaves(policy EmptyLeafPolicy) Option {
+	return func(ms *MerkleService) {
+		ms.emptyLeaves = policy
+	}
+}
+
+// What happens to the unpaired last node of a level: PromoteOddNodes (moved up as is),
+// DuplicateOddNodes (hashed with itself), ZeroPadOddNodes (hashed with a zero digest) or
+// ConstantPadOddNodes (hashed with the constant of WithOddNodeConstant).
+// Default: duplicated by DupeAppend, promoted by PassThrough (BinaryTree levels are never odd,
+// see PairSingleLeaf). Other process types follow their specification.
+func WithOddNodes(policy OddNodePolicy) Option {
+	return func(ms *MerkleService) {
+		ms.oddNodes = policy
+	}
+}
+
+// Hash the unpaired last node of a level with constant (not empty): implies ConstantPadOddNodes.
+func WithOddNodeConstant(constant []byte) Option {
+	return func(ms *MerkleService) {
+		ms.oddNodes = ConstantPadOddNodes
+		ms.oddNodePad = append([]byte{}, constant...)
+	}
+}
+
+// What the root of a single leaf is: SingleLeafAsRoot (the leaf), PairSingleLeaf (the leaf
+// paired as the odd node policy says), HashSingleLeaf (digest of the leaf) or RejectSingleLeaf.
+// Default: paired with itself by DupeAppend, the leaf otherwise.
+// Applies to PassThrough, DupeAppend and BinaryTree.
+func WithSingleLeaf(policy SingleLeafPolicy) Option {
+	return func(ms *MerkleService) {
+		ms.singleLeaf = policy
+	}
+}
+
+// Sort each pair before hashing: hash(min(left, right) || max(left, right)).
+// Node hashing is then commutative, proofs do not depend on sibling positions
+// (as OpenZeppelin's MerkleProof verifies them).
+func WithSortedPairs(sortedPairs bool) Option {
+	return func(ms *MerkleService) {
+		ms.sortedPairs = sortedPairs
+	}
+}
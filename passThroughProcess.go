@@ -11,22 +11,30 @@ func (ms *MerkleService) processPassThroughRequest(ctx context.Context) error {
 		return &InvalidContextProcessTypeErr{contextProcessType.(string)}
 	}
 
+	return ms.passThrough(ctx)
+}
+
+// Pair nodes level by level, an unpaired last node is promoted as is to the next level
+// (or paired, as the odd node policy says).
+func (ms *MerkleService) passThrough(ctx context.Context) error {
+	if len(ms.Leaves) == 1 {
+		return ms.singleLeafRoot()
+	}
+
 	for len(ms.Leaves) > 1 {
-		for index := 0; index < len(ms.Leaves); index += 2 {
-			// - if index to adjacent would overflow stop and leave last element alone,
-			//	wow: pass it through to next branch iteration.
-			if index+1 >= len(ms.Leaves) {
-				break
-			}
-
-			// - combine (concatenate) hash of left and right (in couple)
-			// - encode it with requested algorithm
-			// - Zero (nil) out the right element's value
-			ms.Leaves[index] = ms.hashGenerator(append(ms.Leaves[index][:], ms.Leaves[index+1][:]...))
-			ms.Leaves[index+1] = []byte{}
+		if err := checkContext(ctx); err != nil {
+			return err
 		}
 
-		ms.removeNillBytes(PassThrough, 0)
+		ms.padOddLevel()
+		ms.proveLevel(0)
+
+		// - combine (concatenate) hash of left and right (in couple)
+		// - encode it with requested algorithm
+		// - an unpaired last element is left alone,
+		//	wow: pass it through to next branch iteration.
+		ms.hashLevel(0)
+		ms.recordLevel()
 	}
 
 	ms.ProcessResult = ms.Leaves[0]
@@ -0,0 +1,31 @@
+package merkletree
+
+//
+// RFC 6962 (Certificate Transparency) process type.
+//
+//	- Leaves are the log entries: leaf hash = hash(0x00 || entry).
+//	- Nodes: hash(0x01 || left || right).
+//	- A tree of n leaves is split at the largest power of two smaller than n,
+//	  which is the shape PassThrough builds: the same pairing is used.
+//
+
+import (
+	"context"
+)
+
+// Domain separation prefixes (RFC 6962, section 2.1)
+const (
+	RFC6962LeafPrefix = 0x00
+	RFC6962NodePrefix = 0x01
+)
+
+func (ms *MerkleService) processRFC6962Request(ctx context.Context) error {
+	const ThisProcess = 3
+	contextProcessType := ctx.Value(contextKeyRequestID)
+	if contextProcessType != processTypes[ThisProcess] {
+		return &InvalidContextProcessTypeErr{contextProcessType.(string)}
+	}
+
+	// Leaves are hashed (with the leaf prefix) when the job is set up.
+	return ms.passThrough(ctx)
+}
@@ -0,0 +1,174 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// Certificate Transparency test vectors (certificate-transparency-go, merkle/testonly)
+var (
+	ctLeaves = [][]byte{
+		{},
+		{0x00},
+		{0x10},
+		{0x20, 0x21},
+		{0x30, 0x31},
+		{0x40, 0x41, 0x42, 0x43},
+		{0x50, 0x51, 0x52, 0x53, 0x54, 0x55, 0x56, 0x57},
+		{0x60, 0x61, 0x62, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68, 0x69, 0x6a, 0x6b, 0x6c, 0x6d, 0x6e, 0x6f},
+	}
+
+	// root of the tree of the first i+1 leaves
+	ctRoots = []string{
+		"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+		"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+		"aeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+		"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		"4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+		"76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+		"ddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+		"5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+	}
+
+	ctInclusionProofs = []struct {
+		leafIndex, treeSize int
+		path                []string
+	}{
+		{0, 1, []string{}},
+		{0, 8, []string{
+			"96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7",
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"6b47aaf29ee3c2af9af889bc1fb9254dabd31177f16232dd6aab035ca39bf6e4",
+		}},
+		{5, 8, []string{
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+			"ca854ea128ed050b41b35ffc1b87b8eb2bde461e9e3b5596ece6b9d5975a0ae0",
+			"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		}},
+		{2, 3, []string{
+			"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+		}},
+		{1, 5, []string{
+			"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+		}},
+	}
+
+	ctConsistencyProofs = []struct {
+		m, n int
+		path []string
+	}{
+		{1, 1, []string{}},
+		{1, 8, []string{
+			"96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7",
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"6b47aaf29ee3c2af9af889bc1fb9254dabd31177f16232dd6aab035ca39bf6e4",
+		}},
+		{6, 8, []string{
+			"0ebc5d3437fbe2db158b9f126a1d118e308181031d0a949f8dededebc558ef6a",
+			"ca854ea128ed050b41b35ffc1b87b8eb2bde461e9e3b5596ece6b9d5975a0ae0",
+			"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		}},
+		{2, 5, []string{
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+		}},
+	}
+)
+
+func decodeHexes(t *testing.T, hexes []string) [][]byte {
+	decoded := [][]byte{}
+	for _, h := range hexes {
+		b, err := hex.DecodeString(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded = append(decoded, b)
+	}
+	return decoded
+}
+
+func TestRFC6962Roots(t *testing.T) {
+	for size := 1; size <= len(ctLeaves); size++ {
+		output, err := DeriveRoot(ctLeaves[:size], "SHA256SUM256", RFC6962)
+		if err != nil {
+			t.Fatalf("(err) got %q, wanted nil", err)
+		}
+		if hex.EncodeToString(output) != ctRoots[size-1] {
+			t.Errorf("size %d: (out) got %q, wanted %q", size, hex.EncodeToString(output), ctRoots[size-1])
+		}
+	}
+}
+
+func TestRFC6962InclusionProofs(t *testing.T) {
+	for _, test := range ctInclusionProofs {
+		proof, err := GenerateProof(ctLeaves[:test.treeSize], test.leafIndex, "SHA256SUM256", RFC6962)
+		if err != nil {
+			t.Fatalf("(err) got %q, wanted nil", err)
+		}
+
+		expected := decodeHexes(t, test.path)
+		if len(proof.Path) != len(expected) {
+			t.Fatalf("leaf %d of %d: (path) got %d steps, wanted %d", test.leafIndex, test.treeSize, len(proof.Path), len(expected))
+		}
+		for i, step := range proof.Path {
+			if !bytes.Equal(step.Hash, expected[i]) {
+				t.Errorf("leaf %d of %d, step %d: got %q, wanted %q", test.leafIndex, test.treeSize, i, hex.EncodeToString(step.Hash), test.path[i])
+			}
+		}
+
+		root, _ := hex.DecodeString(ctRoots[test.treeSize-1])
+		if ok, err := VerifyProof(ctLeaves[test.leafIndex], proof, root, "SHA256SUM256", RFC6962); !ok || err != nil {
+			t.Errorf("leaf %d of %d: (verify) got %v (%v), wanted true", test.leafIndex, test.treeSize, ok, err)
+		}
+	}
+}
+
+func TestRFC6962ConsistencyProofs(t *testing.T) {
+	for _, test := range ctConsistencyProofs {
+		proof, err := ConsistencyProof(ctLeaves, test.m, test.n, "SHA256SUM256", RFC6962)
+		if err != nil {
+			t.Fatalf("(err) got %q, wanted nil", err)
+		}
+
+		expected := decodeHexes(t, test.path)
+		if len(proof) != len(expected) {
+			t.Fatalf("%d to %d: (path) got %d nodes, wanted %d", test.m, test.n, len(proof), len(expected))
+		}
+		for i, node := range proof {
+			if !bytes.Equal(node, expected[i]) {
+				t.Errorf("%d to %d, node %d: got %q, wanted %q", test.m, test.n, i, hex.EncodeToString(node), test.path[i])
+			}
+		}
+	}
+
+	// every pair of tree sizes verifies, and not against swapped roots
+	roots := decodeHexes(t, ctRoots)
+	for n := 1; n <= len(ctLeaves); n++ {
+		for m := 1; m <= n; m++ {
+			proof, _ := ConsistencyProof(ctLeaves, m, n, "SHA256SUM256", RFC6962)
+			if ok, err := VerifyConsistency(roots[m-1], roots[n-1], m, n, proof, "SHA256SUM256", RFC6962); !ok || err != nil {
+				t.Errorf("%d to %d: (verify) got %v (%v), wanted true", m, n, ok, err)
+			}
+			if m < n && n > 2 {
+				if ok, _ := VerifyConsistency(roots[n-1], roots[m-1], m, n, proof, "SHA256SUM256", RFC6962); ok {
+					t.Errorf("%d to %d: swapped roots verified", m, n)
+				}
+			}
+		}
+	}
+
+	var unsupported *UnsupportedProcessTypeErr
+	if _, err := ConsistencyProof(ctLeaves, 2, 5, "SHA256SUM256", DupeAppend); !errors.As(err, &unsupported) {
+		t.Errorf("(err) got %v, wanted *UnsupportedProcessTypeErr", err)
+	}
+
+	var prfErr *InvalidProofErr
+	proof, _ := ConsistencyProof(ctLeaves, 2, 5, "SHA256SUM256", RFC6962)
+	if _, err := VerifyConsistency(roots[1], roots[4], 2, 5, proof[:1], "SHA256SUM256", RFC6962); !errors.As(err, &prfErr) {
+		t.Errorf("(err) got %v, wanted *InvalidProofErr", err)
+	}
+}
@@ -0,0 +1,16 @@
+package sparse
+
+import (
+	"fmt"
+)
+
+// Custom error definitions.
+
+// - malformed proof
+type InvalidProofErr struct {
+	reason string
+}
+
+func (prferr *InvalidProofErr) Error() string {
+	return fmt.Sprintf("invalid sparse merkle proof: %s", prferr.reason)
+}
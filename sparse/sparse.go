@@ -0,0 +1,275 @@
+package sparse
+
+//
+// Sparse merkle tree: an authenticated key-value map.
+//
+//	- Every possible key has a leaf: the tree has a fixed depth, the bit length
+//	  of the digest (256 for SHA256SUM256). A key's leaf is at path hash(key).
+//	- Leaves that were never set are empty: empty subtrees have a default hash
+//	  per height, so only the set leaves, and the nodes above them, are stored.
+//	- Nodes are cached by height and prefix (the path bits above them): Set and
+//	  Delete rehash the depth nodes on the key's path, Root and Prove read them.
+//	- Hashing (with a registered merkletree algorithm):
+//		- empty leaf:  zero digest
+//		- leaf:        hash(0x00 || path || hash(value))
+//		- node:        hash(0x01 || left || right)
+//
+// Functions:
+//
+//	- New:
+//		Empty tree for a registered merkletree algorithm.
+//
+//	- Get, Set, Delete, Root:
+//		Map operations, root of the current content.
+//
+//	- Prove, Verify:
+//		Inclusion proof of a key's value, or non-inclusion proof of an absent key.
+//
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"slices"
+
+	"github.com/yveshoebeke/merkletree"
+)
+
+// Domain separation prefixes
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+// Sparse merkle tree. Not safe for concurrent use.
+type Tree struct {
+	HashTypeID string `json:"hashtype"`
+	hash       merkletree.CryptoFunc
+	depth      int
+	defaults   [][]byte           // defaults[h]: root of an empty subtree of height h
+	values     map[string][]byte  // path -> value
+	nodes      map[nodeKey][]byte // non default nodes, leaves (height 0) included
+}
+
+// Position of a node: its height, and the path bits above it (see prefixOf).
+type nodeKey struct {
+	height int
+	prefix string
+}
+
+// Proof of a key: its value (inclusion) or its absence (non-inclusion).
+//   - Siblings are listed from the leaf level up. Default (empty subtree) siblings
+//     are left out: bit h of Bitmap is set when the sibling at height h is listed.
+type Proof struct {
+	Key      []byte   `json:"key"`
+	Value    []byte   `json:"value"`
+	Included bool     `json:"included"`
+	Bitmap   []byte   `json:"bitmap"`
+	Siblings [][]byte `json:"siblings"`
+}
+
+// Empty tree, hashing with algorithm (see merkletree.RegisterAlgorithm).
+func New(algorithm string) (*Tree, error) {
+	// Validates the algorithm the merkletree way.
+	ms, err := merkletree.New(merkletree.WithAlgorithm(algorithm))
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tree{
+		HashTypeID: ms.HashTypeID,
+		hash:       ms.Hash,
+		values:     map[string][]byte{},
+		nodes:      map[nodeKey][]byte{},
+	}
+
+	size := len(t.hash(nil))
+	t.depth = size * 8
+	t.defaults = make([][]byte, t.depth+1)
+	t.defaults[0] = make([]byte, size)
+	for h := 1; h <= t.depth; h++ {
+		t.defaults[h] = t.hashNode(t.defaults[h-1], t.defaults[h-1])
+	}
+
+	return t, nil
+}
+
+// Number of levels (bits of a path).
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// Number of keys set.
+func (t *Tree) Len() int {
+	return len(t.values)
+}
+
+// Value of key, and whether it is set.
+func (t *Tree) Get(key []byte) ([]byte, bool) {
+	value, ok := t.values[string(t.hash(key))]
+	return slices.Clone(value), ok
+}
+
+// Set key to value. The value is copied.
+func (t *Tree) Set(key, value []byte) {
+	path := t.hash(key)
+	t.values[string(path)] = slices.Clone(value)
+	t.update(path, t.hashLeaf(path, value))
+}
+
+// Delete key: its leaf is empty again.
+func (t *Tree) Delete(key []byte) {
+	path := t.hash(key)
+	if _, ok := t.values[string(path)]; ok {
+		delete(t.values, string(path))
+		t.update(path, t.defaults[0])
+	}
+}
+
+// Root of the current content.
+func (t *Tree) Root() []byte {
+	return slices.Clone(t.node(t.depth, nil))
+}
+
+// Inclusion proof of key if it is set, non-inclusion proof otherwise.
+func (t *Tree) Prove(key []byte) *Proof {
+	path := t.hash(key)
+	value, included := t.values[string(path)]
+
+	proof := &Proof{
+		Key:      slices.Clone(key),
+		Value:    slices.Clone(value),
+		Included: included,
+		Bitmap:   make([]byte, (t.depth+7)/8),
+		Siblings: [][]byte{},
+	}
+
+	// The sibling at height h: same path bits above it, but the last one.
+	for height := 0; height < t.depth; height++ {
+		prefix := prefixOf(path, t.depth-height)
+		flipBit(prefix, t.depth-1-height)
+		if sibling, ok := t.nodes[nodeKey{height, string(prefix)}]; ok {
+			proof.Bitmap[height/8] |= 1 << (height % 8)
+			proof.Siblings = append(proof.Siblings, slices.Clone(sibling))
+		}
+	}
+
+	return proof
+}
+
+// Checks proof against root: key has proof.Value (inclusion) or is absent (non-inclusion).
+// A malformed proof returns an error; a well-formed proof that does not match root returns false.
+func Verify(root []byte, proof *Proof, algorithm string) (bool, error) {
+	t, err := New(algorithm)
+	if err != nil {
+		return false, err
+	}
+
+	return t.Verify(root, proof)
+}
+
+// Checks proof against root, with the tree's algorithm.
+func (t *Tree) Verify(root []byte, proof *Proof) (bool, error) {
+	if proof == nil {
+		return false, &InvalidProofErr{"missing proof"}
+	}
+	if len(proof.Bitmap) != (t.depth+7)/8 {
+		return false, &InvalidProofErr{fmt.Sprintf("bitmap of %d bytes, expected %d", len(proof.Bitmap), (t.depth+7)/8)}
+	}
+
+	path := t.hash(proof.Key)
+	node := t.defaults[0]
+	if proof.Included {
+		node = t.hashLeaf(path, proof.Value)
+	}
+
+	siblings := proof.Siblings
+	for height := 0; height < t.depth; height++ {
+		sibling := t.defaults[height]
+		if proof.Bitmap[height/8]&(1<<(height%8)) != 0 {
+			if len(siblings) == 0 {
+				return false, &InvalidProofErr{"missing siblings"}
+			}
+			sibling, siblings = siblings[0], siblings[1:]
+		}
+
+		if bitAt(path, t.depth-1-height) == 0 {
+			node = t.hashNode(node, sibling)
+		} else {
+			node = t.hashNode(sibling, node)
+		}
+	}
+
+	if len(siblings) != 0 {
+		return false, &InvalidProofErr{"too many siblings"}
+	}
+
+	return subtle.ConstantTimeCompare(node, root) == 1, nil
+}
+
+// Set the leaf at path, and rehash the nodes above it, up to the root.
+func (t *Tree) update(path, leaf []byte) {
+	node := leaf
+	t.store(0, path, node)
+
+	// prefix: the path, its bits cleared from the node's height up (see prefixOf)
+	prefix := slices.Clone(path)
+	for height := 1; height <= t.depth; height++ {
+		bit := t.depth - height
+		flipBit(prefix, bit)
+		sibling := t.node(height-1, prefix[:(bit+8)/8])
+		flipBit(prefix, bit)
+
+		if bitAt(prefix, bit) == 0 {
+			node = t.hashNode(node, sibling)
+		} else {
+			node = t.hashNode(sibling, node)
+			flipBit(prefix, bit)
+		}
+		t.store(height, prefix[:(bit+7)/8], node)
+	}
+}
+
+// Node of height under prefix: cached, or the default one.
+func (t *Tree) node(height int, prefix []byte) []byte {
+	if node, ok := t.nodes[nodeKey{height, string(prefix)}]; ok {
+		return node
+	}
+	return t.defaults[height]
+}
+
+// Cache node, or forget it if it is the default one.
+func (t *Tree) store(height int, prefix, node []byte) {
+	if bytes.Equal(node, t.defaults[height]) {
+		delete(t.nodes, nodeKey{height, string(prefix)})
+		return
+	}
+	t.nodes[nodeKey{height, string(prefix)}] = node
+}
+
+func (t *Tree) hashLeaf(path, value []byte) []byte {
+	return t.hash(slices.Concat([]byte{leafPrefix}, path, t.hash(value)))
+}
+
+func (t *Tree) hashNode(left, right []byte) []byte {
+	return t.hash(slices.Concat([]byte{nodePrefix}, left, right))
+}
+
+// Bit i of path, most significant bit first.
+func bitAt(path []byte, i int) byte {
+	return (path[i/8] >> (7 - i%8)) & 1
+}
+
+// Copy of the first bits of path, on as many bytes as they need (the rest of the last one is zero).
+func prefixOf(path []byte, bits int) []byte {
+	prefix := slices.Clone(path[:(bits+7)/8])
+	if bits%8 != 0 {
+		prefix[len(prefix)-1] &= ^byte(0xff >> (bits % 8))
+	}
+	return prefix
+}
+
+// Flip bit i of prefix, most significant bit first.
+func flipBit(prefix []byte, i int) {
+	prefix[i/8] ^= 1 << (7 - i%8)
+}
@@ -0,0 +1,123 @@
+package sparse
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSparseTree(t *testing.T) {
+	tree, err := New("SHA256SUM256")
+	if err != nil {
+		t.Fatalf("(err) got %q, wanted nil", err)
+	}
+	if tree.Depth() != 256 {
+		t.Errorf("(depth) got %d, wanted 256", tree.Depth())
+	}
+
+	emptyRoot := tree.Root()
+
+	for i := 0; i < 50; i++ {
+		tree.Set([]byte(fmt.Sprint("key", i)), []byte(fmt.Sprint("value", i)))
+	}
+	fullRoot := tree.Root()
+
+	// insertion order does not matter
+	other, _ := New("SHA256SUM256")
+	for i := 49; i >= 0; i-- {
+		other.Set([]byte(fmt.Sprint("key", i)), []byte(fmt.Sprint("value", i)))
+	}
+	if !bytes.Equal(other.Root(), fullRoot) {
+		t.Errorf("(root) depends on insertion order")
+	}
+
+	if value, ok := tree.Get([]byte("key7")); !ok || string(value) != "value7" {
+		t.Errorf("(get) got %q %v, wanted value7 true", value, ok)
+	}
+	if _, ok := tree.Get([]byte("nope")); ok {
+		t.Errorf("(get) absent key found")
+	}
+
+	// update and delete change the root; deleting what was added restores it
+	tree.Set([]byte("key7"), []byte("changed"))
+	if bytes.Equal(tree.Root(), fullRoot) {
+		t.Errorf("(root) unchanged after update")
+	}
+	tree.Set([]byte("key7"), []byte("value7"))
+	tree.Set([]byte("extra"), []byte{})
+	tree.Delete([]byte("extra"))
+	if !bytes.Equal(tree.Root(), fullRoot) {
+		t.Errorf("(root) not restored after delete")
+	}
+	if tree.Len() != 50 {
+		t.Errorf("(len) got %d, wanted 50", tree.Len())
+	}
+
+	for i := 0; i < 50; i++ {
+		tree.Delete([]byte(fmt.Sprint("key", i)))
+	}
+	if !bytes.Equal(tree.Root(), emptyRoot) {
+		t.Errorf("(root) of emptied tree is not the empty root")
+	}
+	if len(tree.nodes) != 0 {
+		t.Errorf("(nodes) emptied tree still caches %d nodes", len(tree.nodes))
+	}
+}
+
+func TestSparseProofs(t *testing.T) {
+	tree, _ := New("SHA256SUM256")
+	for i := 0; i < 20; i++ {
+		tree.Set([]byte(fmt.Sprint("key", i)), []byte(fmt.Sprint("value", i)))
+	}
+	root := tree.Root()
+
+	// inclusion
+	for i := 0; i < 20; i++ {
+		proof := tree.Prove([]byte(fmt.Sprint("key", i)))
+		if !proof.Included || string(proof.Value) != fmt.Sprint("value", i) {
+			t.Fatalf("key%d: (proof) not an inclusion proof of its value", i)
+		}
+		if ok, err := Verify(root, proof, "SHA256SUM256"); !ok || err != nil {
+			t.Errorf("key%d: (verify) got %v (%v), wanted true", i, ok, err)
+		}
+
+		proof.Value = []byte("forged")
+		if ok, _ := Verify(root, proof, "SHA256SUM256"); ok {
+			t.Errorf("key%d: forged value verified", i)
+		}
+	}
+
+	// non-inclusion
+	proof := tree.Prove([]byte("absent"))
+	if proof.Included {
+		t.Fatalf("(proof) absent key included")
+	}
+	if ok, err := tree.Verify(root, proof); !ok || err != nil {
+		t.Errorf("(verify) got %v (%v), wanted true", ok, err)
+	}
+
+	// a non-inclusion proof of a present key fails
+	proof = tree.Prove([]byte("key3"))
+	proof.Included = false
+	if ok, _ := tree.Verify(root, proof); ok {
+		t.Errorf("present key proven absent")
+	}
+
+	// malformed
+	var prfErr *InvalidProofErr
+	proof = tree.Prove([]byte("key3"))
+	proof.Siblings = proof.Siblings[1:]
+	if _, err := tree.Verify(root, proof); !errors.As(err, &prfErr) {
+		t.Errorf("(err) got %v, wanted *InvalidProofErr", err)
+	}
+	if _, err := tree.Verify(root, nil); !errors.As(err, &prfErr) {
+		t.Errorf("(err) got %v, wanted *InvalidProofErr", err)
+	}
+
+	for _, algorithm := range []string{"NOPE", ""} {
+		if _, err := New(algorithm); err == nil {
+			t.Errorf("(err) unknown algorithm %q accepted", algorithm)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package merkletree
+
+//
+// OpenZeppelin StandardMerkleTree compatibility (@openzeppelin/merkle-tree).
+//
+// Process type StandardMerkleTree:
+//
+//	- Data: the ABI encoded values (see abiEncoding.go).
+//	- Leaves: keccak256(keccak256(data)).
+//	- Leaves are sorted, then laid out from the end of a heap array of 2n-1 nodes
+//	  (node i has children 2i+1 and 2i+2). With the leaves in descending order,
+//	  that is the BinaryTree pairing (from the same starting index), except that
+//	  in the next level the new nodes come before the unpaired leaves.
+//	- Pairs are sorted before hashing, as OpenZeppelin's MerkleProof does on-chain.
+//
+// StandardTree is the tree with its values, as dumped to and loaded from
+// OpenZeppelin's JSON format ("standard-v1").
+//
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const StandardTreeFormat = "standard-v1"
+
+func (ms *MerkleService) processStandardMerkleTreeRequest(ctx context.Context) error {
+	const ThisProcess = 4
+	contextProcessType := ctx.Value(contextKeyRequestID)
+	if contextProcessType != processTypes[ThisProcess] {
+		return &InvalidContextProcessTypeErr{contextProcessType.(string)}
+	}
+
+	// Single leaf: it is the root.
+	if len(ms.Leaves) == 1 {
+		ms.ProcessResult = ms.Leaves[0]
+		return nil
+	}
+
+	// Leaves (already double hashed) in descending order, keeping track of the proven one.
+	order := make([]int, len(ms.Leaves))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return bytes.Compare(ms.Leaves[b], ms.Leaves[a])
+	})
+
+	proven := ms.proofIndex
+	sorted := make([][]byte, len(ms.Leaves))
+	for position, index := range order {
+		sorted[position] = ms.Leaves[index]
+		if index == proven {
+			ms.proofIndex = position
+		}
+	}
+	ms.Leaves = sorted
+
+	// A retained tree holds its leaves in tree order.
+	if ms.retainLevels {
+		ms.levels[0] = slices.Clone(sorted)
+	}
+
+	// First level: pair from the starting index, new nodes first, then the unpaired leaves.
+	startIndex := binaryTreeStartIndex(len(sorted))
+	pairs := (len(sorted) - startIndex) / 2
+
+	ms.proveLevel(startIndex)
+	if ms.ProofRequest {
+		ms.proofIndex = If(ms.proofIndex < startIndex, pairs+ms.proofIndex, ms.proofIndex-startIndex)
+	}
+
+	// (the sorted leaves are not in the digest buffer: the new nodes go to its first slots)
+	ms.growNodes(pairs)
+	ms.shard(pairs, func(worker *MerkleService, from, to int) {
+		for pair := from; pair < to; pair++ {
+			index := startIndex + 2*pair
+			hashPairInto(worker, ms.slot(pair), sorted[index], sorted[index+1])
+		}
+	})
+	ms.Leaves = make([][]byte, 0, startIndex+pairs)
+	for pair := 0; pair < pairs; pair++ {
+		ms.Leaves = append(ms.Leaves, ms.slot(pair))
+	}
+	ms.Leaves = append(ms.Leaves, sorted[:startIndex]...)
+	ms.recordLevel()
+
+	return ms.pairLevels(ctx)
+}
+
+// Hex encoded ("0x...") bytes in JSON.
+type HexBytes []byte
+
+func (h HexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal("0x" + hex.EncodeToString(h))
+}
+
+func (h *HexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(s, "0x") {
+		return &InvalidStandardTreeErr{fmt.Sprintf("hex value without 0x prefix: %q", s)}
+	}
+
+	decoded, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return err
+	}
+	*h = decoded
+
+	return nil
+}
+
+// A value of the tree and the index of its leaf in the tree array.
+type StandardTreeValue struct {
+	Value     []any `json:"value"`
+	TreeIndex int   `json:"treeIndex"`
+}
+
+// OpenZeppelin StandardMerkleTree, in its JSON dump layout.
+type StandardTree struct {
+	Format       string              `json:"format"`
+	LeafEncoding []string            `json:"leafEncoding"`
+	Tree         []HexBytes          `json:"tree"`
+	Values       []StandardTreeValue `json:"values"`
+}
+
+// Leaf hash of a value: keccak256(keccak256(abi.encode(value))).
+func StandardLeafHash(value []any, leafEncoding []string) ([]byte, error) {
+	data, err := abiEncode(leafEncoding, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return KECCAK256(KECCAK256(data)), nil
+}
+
+// Tree of values, each ABI encoded with leafEncoding (ie: []string{"address", "uint256"}).
+// Same as StandardMerkleTree.of(values, leafEncoding) in @openzeppelin/merkle-tree.
+func NewStandardTree(values [][]any, leafEncoding []string) (*StandardTree, error) {
+	if len(values) == 0 {
+		return nil, &ArgumentErr{"empty data - "}
+	}
+
+	hashes := make([][]byte, len(values))
+	for i, value := range values {
+		hash, err := StandardLeafHash(value, leafEncoding)
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hash
+	}
+
+	// Leaves sorted by hash
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return bytes.Compare(hashes[a], hashes[b])
+	})
+
+	st := &StandardTree{
+		Format:       StandardTreeFormat,
+		LeafEncoding: slices.Clone(leafEncoding),
+		Tree:         make([]HexBytes, 2*len(values)-1),
+		Values:       make([]StandardTreeValue, len(values)),
+	}
+
+	// Leaves from the end of the array, then the nodes up to the root (index 0).
+	last := len(st.Tree) - 1
+	for position, index := range order {
+		st.Tree[last-position] = hashes[index]
+		st.Values[index] = StandardTreeValue{Value: slices.Clone(values[index]), TreeIndex: last - position}
+	}
+
+	hasher := standardTreeHasher()
+	for i := last - len(values); i >= 0; i-- {
+		st.Tree[i] = hasher.hashPair(st.Tree[2*i+1], st.Tree[2*i+2])
+	}
+
+	return st, nil
+}
+
+// Tree from its JSON dump. The tree is checked: leaves must match the values, nodes their children.
+func LoadStandardTree(data []byte) (*StandardTree, error) {
+	st := &StandardTree{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(st); err != nil {
+		return nil, err
+	}
+
+	if err := st.validate(); err != nil {
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// JSON dump, as StandardMerkleTree.dump() produces it.
+func (st *StandardTree) Dump() ([]byte, error) {
+	return json.Marshal(st)
+}
+
+// Root of the tree.
+func (st *StandardTree) Root() []byte {
+	return st.Tree[0]
+}
+
+// ABI encoded values, in value order: the data for the StandardMerkleTree process type.
+func (st *StandardTree) Leaves() ([][]byte, error) {
+	leaves := make([][]byte, len(st.Values))
+	for i, value := range st.Values {
+		data, err := abiEncode(st.LeafEncoding, value.Value)
+		if err != nil {
+			return nil, err
+		}
+		leaves[i] = data
+	}
+
+	return leaves, nil
+}
+
+// Proof of the value at valueIndex, as StandardMerkleTree.getProof(i): sibling hashes from the leaf up.
+func (st *StandardTree) Proof(valueIndex int) ([][]byte, error) {
+	if valueIndex < 0 || valueIndex >= len(st.Values) {
+		return nil, &LeafIndexErr{valueIndex, len(st.Values)}
+	}
+
+	proof := [][]byte{}
+	for i := st.Values[valueIndex].TreeIndex; i > 0; i = (i - 1) / 2 {
+		sibling := If(i%2 == 1, i+1, i-1)
+		proof = append(proof, slices.Clone(st.Tree[sibling]))
+	}
+
+	return proof, nil
+}
+
+// Checks a proof of value against root, as StandardMerkleTree.verify (and MerkleProof.verify on-chain).
+func VerifyStandardTreeProof(root []byte, value []any, leafEncoding []string, proof [][]byte) (bool, error) {
+	node, err := StandardLeafHash(value, leafEncoding)
+	if err != nil {
+		return false, err
+	}
+
+	hasher := standardTreeHasher()
+	for _, sibling := range proof {
+		node = hasher.hashPair(node, sibling)
+	}
+
+	return subtle.ConstantTimeCompare(node, root) == 1, nil
+}
+
+// Tree must hold together: format, leaves of the values, nodes of their children.
+func (st *StandardTree) validate() error {
+	if st.Format != StandardTreeFormat {
+		return &InvalidStandardTreeErr{fmt.Sprintf("unknown format %q", st.Format)}
+	}
+	if len(st.Values) == 0 || len(st.Tree) != 2*len(st.Values)-1 {
+		return &InvalidStandardTreeErr{fmt.Sprintf("%d nodes for %d values", len(st.Tree), len(st.Values))}
+	}
+
+	firstLeaf := len(st.Tree) - len(st.Values)
+	for i, value := range st.Values {
+		if value.TreeIndex < firstLeaf || value.TreeIndex >= len(st.Tree) {
+			return &InvalidStandardTreeErr{fmt.Sprintf("value %d: tree index %d is not a leaf", i, value.TreeIndex)}
+		}
+		hash, err := StandardLeafHash(value.Value, st.LeafEncoding)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(hash, st.Tree[value.TreeIndex]) {
+			return &InvalidStandardTreeErr{fmt.Sprintf("value %d does not match its leaf", i)}
+		}
+	}
+
+	hasher := standardTreeHasher()
+	for i := firstLeaf - 1; i >= 0; i-- {
+		if !bytes.Equal(st.Tree[i], hasher.hashPair(st.Tree[2*i+1], st.Tree[2*i+2])) {
+			return &InvalidStandardTreeErr{fmt.Sprintf("node %d does not match its children", i)}
+		}
+	}
+
+	return nil
+}
+
+// Service (working copy) hashing sorted keccak256 pairs.
+func standardTreeHasher() *MerkleService {
+	ms, _ := New(WithProcessType(StandardMerkleTree))
+	return ms
+}
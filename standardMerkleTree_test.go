@@ -0,0 +1,163 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// @openzeppelin/merkle-tree README example
+var (
+	ozValues = [][]any{
+		{"0x1111111111111111111111111111111111111111", "5000000000000000000"},
+		{"0x2222222222222222222222222222222222222222", "2500000000000000000"},
+	}
+	ozEncoding = []string{"address", "uint256"}
+	ozRoot     = "d4dee0beab2d53f2cc83e567171bd2820e49898130a22622b10ead383e90bd77"
+)
+
+func makeStandardValues(count int) [][]any {
+	values := [][]any{}
+	for i := 0; i < count; i++ {
+		values = append(values, []any{fmt.Sprintf("0x%040x", i+1), fmt.Sprint(i * 1000)})
+	}
+	return values
+}
+
+func TestStandardTree(t *testing.T) {
+	st, err := NewStandardTree(ozValues, ozEncoding)
+	if err != nil {
+		t.Fatalf("(err) got %q, wanted nil", err)
+	}
+	if hex.EncodeToString(st.Root()) != ozRoot {
+		t.Errorf("(root) got %q, wanted %q", hex.EncodeToString(st.Root()), ozRoot)
+	}
+
+	for count := 1; count <= 13; count++ {
+		values := makeStandardValues(count)
+		st, err := NewStandardTree(values, ozEncoding)
+		if err != nil {
+			t.Fatalf("(err) got %q, wanted nil", err)
+		}
+
+		// process type gives the same root and proofs
+		leaves, _ := st.Leaves()
+		root, err := DeriveRoot(leaves, "KECCAK256", StandardMerkleTree)
+		if err != nil || !bytes.Equal(root, st.Root()) {
+			t.Errorf("%d values: (root) got %q (%v), wanted %q", count, hex.EncodeToString(root), err, hex.EncodeToString(st.Root()))
+		}
+
+		for i := range values {
+			ozProof, _ := st.Proof(i)
+			if ok, err := VerifyStandardTreeProof(st.Root(), values[i], ozEncoding, ozProof); !ok || err != nil {
+				t.Errorf("%d values, value %d: (verify) got %v (%v), wanted true", count, i, ok, err)
+			}
+
+			proof, err := GenerateProof(leaves, i, "KECCAK256", StandardMerkleTree)
+			if err != nil {
+				t.Fatalf("(err) got %q, wanted nil", err)
+			}
+			if len(proof.Path) != len(ozProof) {
+				t.Fatalf("%d values, value %d: (path) got %d steps, wanted %d", count, i, len(proof.Path), len(ozProof))
+			}
+			for level, step := range proof.Path {
+				if !bytes.Equal(step.Hash, ozProof[level]) {
+					t.Errorf("%d values, value %d: (path) step %d differs", count, i, level)
+				}
+			}
+			if ok, err := VerifyProof(leaves[i], proof, root, "KECCAK256", StandardMerkleTree); !ok || err != nil {
+				t.Errorf("%d values, value %d: (verify) got %v (%v), wanted true", count, i, ok, err)
+			}
+		}
+	}
+}
+
+func TestStandardTreeDump(t *testing.T) {
+	st, _ := NewStandardTree(makeStandardValues(5), ozEncoding)
+	dump, err := st.Dump()
+	if err != nil {
+		t.Fatalf("(err) got %q, wanted nil", err)
+	}
+	if !strings.HasPrefix(string(dump), `{"format":"standard-v1","leafEncoding":["address","uint256"],"tree":["0x`) {
+		t.Errorf("(dump) got %s", dump)
+	}
+
+	loaded, err := LoadStandardTree(dump)
+	if err != nil {
+		t.Fatalf("(err) got %q, wanted nil", err)
+	}
+	if !bytes.Equal(loaded.Root(), st.Root()) {
+		t.Errorf("(root) got %q, wanted %q", hex.EncodeToString(loaded.Root()), hex.EncodeToString(st.Root()))
+	}
+	proof, _ := loaded.Proof(3)
+	if ok, _ := VerifyStandardTreeProof(st.Root(), loaded.Values[3].Value, ozEncoding, proof); !ok {
+		t.Errorf("(verify) proof from loaded tree failed")
+	}
+
+	// tampered value
+	tampered := strings.Replace(string(dump), `"3000"`, `"3001"`, 1)
+	var treeErr *InvalidStandardTreeErr
+	if _, err := LoadStandardTree([]byte(tampered)); !errors.As(err, &treeErr) {
+		t.Errorf("(err) got %v, wanted *InvalidStandardTreeErr", err)
+	}
+}
+
+func TestABIEncode(t *testing.T) {
+	for _, test := range []struct {
+		types    []string
+		values   []any
+		expected string
+	}{
+		{[]string{"uint8", "bool"}, []any{255, true},
+			"00000000000000000000000000000000000000000000000000000000000000ff" +
+				"0000000000000000000000000000000000000000000000000000000000000001"},
+		{[]string{"int256"}, []any{"-1"},
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{[]string{"bytes2", "string"}, []any{"0xabcd", "hi"},
+			"abcd000000000000000000000000000000000000000000000000000000000000" +
+				"0000000000000000000000000000000000000000000000000000000000000040" +
+				"0000000000000000000000000000000000000000000000000000000000000002" +
+				"6869000000000000000000000000000000000000000000000000000000000000"},
+	} {
+		output, err := abiEncode(test.types, test.values)
+		if err != nil || hex.EncodeToString(output) != test.expected {
+			t.Errorf("%v: got %x (%v), wanted %s", test.types, output, err, test.expected)
+		}
+	}
+
+	var abiErr *ABIEncodingErr
+	for _, test := range []struct {
+		types  []string
+		values []any
+	}{
+		{[]string{"uint8"}, []any{256}},
+		{[]string{"address"}, []any{"0x1234"}},
+		{[]string{"uint7"}, []any{1}},
+		{[]string{"tuple"}, []any{1}},
+		{[]string{"bool"}, []any{}},
+	} {
+		if _, err := abiEncode(test.types, test.values); !errors.As(err, &abiErr) {
+			t.Errorf("%v: (err) got %v, wanted *ABIEncodingErr", test.types, err)
+		}
+	}
+}
+
+func TestSortedPairs(t *testing.T) {
+	ms, _ := New(WithProcessType(DupeAppend), WithSortedPairs(true))
+	leaves := makeIndexedLeaves(6)
+	root, _ := ms.Derive(leaves)
+
+	// siblings swapped within pairs: same root
+	swapped := [][]byte{leaves[1], leaves[0], leaves[3], leaves[2], leaves[5], leaves[4]}
+	if output, _ := ms.Derive(swapped); !bytes.Equal(output, root) {
+		t.Errorf("(out) got %q, wanted %q", hex.EncodeToString(output), hex.EncodeToString(root))
+	}
+
+	var argErr *ArgumentErr
+	if _, err := New(WithProcessType(StandardMerkleTree), WithAlgorithm("SHA256SUM256")); !errors.As(err, &argErr) {
+		t.Errorf("(err) got %v, wanted *ArgumentErr", err)
+	}
+}
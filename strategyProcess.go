@@ -0,0 +1,247 @@
+package merkletree
+
+//
+// Custom process types (tree layouts), registered at runtime.
+//
+// Functions:
+//
+//	- RegisterProcessType:
+//		Adds a process type laid out by a Strategy, returns its value (for WithProcessType,
+//		DeriveRoot, ...). Safe for concurrent use.
+//
+//	- strategyProcess:
+//		Runs a Strategy like the built-in process types: under the request's context
+//		(timeout, cancellation), with the service's hashing (algorithm, leaf hashing,
+//		domain separation, sorted pairs), proofs and retained trees.
+//
+// Notes:
+//
+//	- A single leaf is the root.
+//	- A strategy is checked when registered (trees of up to strategyCheckLeaves leaves),
+//	  and every level it plans when it runs.
+//	- Consistency proofs, mutation detection and the O(log n) builder are not available:
+//	  the builder keeps the leaves (as for BinaryTree).
+//
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Tree layout of a custom process type, one level at a time.
+type Strategy interface {
+	// Next level of a level of count nodes (count > 1, level 0 holds the leaves):
+	// one Pair per node of the next level, with indices in the current level.
+	// The next level must have fewer than count nodes, every node of the level
+	// is in exactly one Pair.
+	NextLevel(level, count int) []Pair
+}
+
+// Node of the next level: hash(level[Left] || level[Right]).
+// Right == Left duplicates the node, Right == Promote passes level[Left] through as is.
+type Pair struct {
+	Left, Right int
+}
+
+// Pair.Right of a node passed through to the next level.
+const Promote = -1
+
+// Custom process type.
+type customProcessType struct {
+	name     string
+	strategy Strategy
+}
+
+// Largest tree a strategy is checked with when registered.
+const strategyCheckLeaves = 16
+
+var (
+	processTypeLock    sync.RWMutex
+	customProcessTypes = map[int]customProcessType{}
+)
+
+/*
+Runtime registration (process type)
+  - Adds a process type named name (its context value, unique), laid out by strategy.
+    Returns the process type's value.
+  - The strategy must lay out trees of 2 to strategyCheckLeaves leaves (see levelPlan).
+*/
+func RegisterProcessType(name string, strategy Strategy) (int, error) {
+	if name == "" || strategy == nil {
+		return NopProcess, &ArgumentErr{"process type name and strategy required - "}
+	}
+	for leafCount := 2; leafCount <= strategyCheckLeaves; leafCount++ {
+		for level, count := 0, leafCount; count > 1; level++ {
+			pairs, err := levelPlan(name, strategy, level, count)
+			if err != nil {
+				return NopProcess, err
+			}
+			count = len(pairs)
+		}
+	}
+
+	processTypeLock.Lock()
+	defer processTypeLock.Unlock()
+
+	if slices.ContainsFunc(processTypes[:], func(builtin string) bool { return strings.EqualFold(builtin, name) }) {
+		return NopProcess, &DuplicateProcessTypeErr{name}
+	}
+	for _, custom := range customProcessTypes {
+		if strings.EqualFold(custom.name, name) {
+			return NopProcess, &DuplicateProcessTypeErr{name}
+		}
+	}
+
+	processType := len(processTypes) + len(customProcessTypes)
+	customProcessTypes[processType] = customProcessType{name, strategy}
+
+	return processType, nil
+}
+
+// Registered custom process type.
+func lookupProcessType(processType int) (customProcessType, bool) {
+	processTypeLock.RLock()
+	defer processTypeLock.RUnlock()
+
+	custom, ok := customProcessTypes[processType]
+	return custom, ok
+}
+
+// Process type function of a custom process type.
+func strategyProcess(custom customProcessType) processTypeFunction {
+	return func(ms *MerkleService, ctx context.Context) error {
+		contextProcessType := ctx.Value(contextKeyRequestID)
+		if contextProcessType != custom.name {
+			return &InvalidContextProcessTypeErr{contextProcessType.(string)}
+		}
+
+		return ms.layOut(ctx, custom.name, custom.strategy)
+	}
+}
+
+// Build the tree level by level as planned by the strategy (of process type name).
+func (ms *MerkleService) layOut(ctx context.Context, name string, strategy Strategy) error {
+	for level := 0; len(ms.Leaves) > 1; level++ {
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
+
+		pairs, err := levelPlan(name, strategy, level, len(ms.Leaves))
+		if err != nil {
+			return err
+		}
+
+		if ms.ProofRequest {
+			sibling, position, next := planSibling(pairs, ms.proofIndex)
+			if sibling >= 0 {
+				ms.ProofResult.Path = append(ms.ProofResult.Path, ProofStep{
+					Hash:     slices.Clone(ms.Leaves[sibling]),
+					Position: position,
+				})
+			}
+			ms.proofIndex = next
+		}
+
+		// Any node may be read by any pair: the level is computed into the scratch buffer,
+		// then copied to the digest buffer. A promoted node that is not a digest stays a header.
+		ms.growNodes(len(ms.Leaves))
+		scratch, size := ms.scratchSlots(len(pairs)), ms.digestSize
+		headers, kept := make([][]byte, len(pairs)), make([]bool, len(pairs))
+		ms.shard(len(pairs), func(worker *MerkleService, from, to int) {
+			for i := from; i < to; i++ {
+				left, dst := ms.Leaves[pairs[i].Left], scratch[i*size:(i+1)*size]
+				switch {
+				case pairs[i].Right != Promote:
+					hashPairInto(worker, dst, left, ms.Leaves[pairs[i].Right])
+				case len(left) == size:
+					copy(dst, left)
+				default:
+					headers[i], kept[i] = left, true
+				}
+			}
+		})
+
+		copy(ms.nodes, scratch)
+		ms.Leaves = ms.Leaves[:len(pairs)]
+		for i := range pairs {
+			ms.Leaves[i] = If(kept[i], headers[i], ms.slot(i))
+		}
+		ms.recordLevel()
+	}
+
+	ms.ProcessResult = ms.Leaves[0]
+
+	return nil
+}
+
+// Strategy's (of process type name) next level of a level of count nodes, checked:
+// fewer nodes, pairs in range, every node of the level in exactly one pair.
+func levelPlan(name string, strategy Strategy, level, count int) ([]Pair, error) {
+	pairs := strategy.NextLevel(level, count)
+	if len(pairs) == 0 || len(pairs) >= count {
+		return nil, &InvalidStrategyErr{name, fmt.Sprintf("level %d: %d nodes for %d", level, len(pairs), count)}
+	}
+
+	consumed := make([]bool, count)
+	consume := func(index int) bool {
+		if consumed[index] {
+			return false
+		}
+		consumed[index] = true
+		return true
+	}
+	for _, pair := range pairs {
+		if pair.Left < 0 || pair.Left >= count || pair.Right < Promote || pair.Right >= count {
+			return nil, &InvalidStrategyErr{name, fmt.Sprintf("level %d: pair %v out of range", level, pair)}
+		}
+		if !consume(pair.Left) || (pair.Right != Promote && pair.Right != pair.Left && !consume(pair.Right)) {
+			return nil, &InvalidStrategyErr{name, fmt.Sprintf("level %d: pair %v reuses a node", level, pair)}
+		}
+	}
+	if index := slices.Index(consumed, false); index >= 0 {
+		return nil, &InvalidStrategyErr{name, fmt.Sprintf("level %d: node %d has no parent", level, index)}
+	}
+
+	return pairs, nil
+}
+
+// Sibling of the node at index (-1 if it is promoted), the sibling's position
+// and the node's parent index (-1 if it has none, not in a checked plan).
+func planSibling(pairs []Pair, index int) (sibling int, position SiblingPosition, next int) {
+	for i, pair := range pairs {
+		switch index {
+		case pair.Left:
+			return If(pair.Right == Promote, -1, pair.Right), SiblingRight, i
+		case pair.Right:
+			return pair.Left, SiblingLeft, i
+		}
+	}
+
+	return -1, SiblingRight, -1
+}
+
+// Sibling positions, per level, of the audit path of leafIndex (see proofPositions).
+func strategyPositions(name string, strategy Strategy, leafIndex, leafCount int) []SiblingPosition {
+	positions := []SiblingPosition{}
+	index := leafIndex
+
+	for level, count := 0, leafCount; count > 1; level++ {
+		pairs, err := levelPlan(name, strategy, level, count)
+		if err != nil {
+			break
+		}
+		sibling, position, next := planSibling(pairs, index)
+		if next < 0 {
+			break
+		}
+		if sibling >= 0 {
+			positions = append(positions, position)
+		}
+		index, count = next, len(pairs)
+	}
+
+	return positions
+}
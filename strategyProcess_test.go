@@ -0,0 +1,148 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// DupeAppend as a strategy: the last node of an odd level is duplicated.
+type duplicateLastStrategy struct{}
+
+func (duplicateLastStrategy) NextLevel(level, count int) []Pair {
+	pairs := []Pair{}
+	for i := 0; i < count; i += 2 {
+		pairs = append(pairs, Pair{i, min(i+1, count-1)})
+	}
+	return pairs
+}
+
+// Pairs hashed right node first, the last node of an odd level promoted.
+type swappedStrategy struct{}
+
+func (swappedStrategy) NextLevel(level, count int) []Pair {
+	pairs := []Pair{}
+	for i := 0; i < count; i += 2 {
+		pairs = append(pairs, If(i+1 < count, Pair{i + 1, i}, Pair{i, Promote}))
+	}
+	return pairs
+}
+
+// Plans a level as large as the current one.
+type stuckStrategy struct{}
+
+func (stuckStrategy) NextLevel(level, count int) []Pair {
+	return make([]Pair, count)
+}
+
+// Pairs node 0 with every other node.
+type reusingStrategy struct{}
+
+func (reusingStrategy) NextLevel(level, count int) []Pair {
+	pairs := []Pair{}
+	for i := 1; i < count; i += 2 {
+		pairs = append(pairs, Pair{0, i})
+	}
+	return pairs
+}
+
+// Drops the last node of large levels (larger than registration checks).
+type droppingStrategy struct{ duplicateLastStrategy }
+
+func (s droppingStrategy) NextLevel(level, count int) []Pair {
+	if count > 2*strategyCheckLeaves {
+		return s.duplicateLastStrategy.NextLevel(level, count-1)
+	}
+	return s.duplicateLastStrategy.NextLevel(level, count)
+}
+
+// Takes its time on large levels.
+type slowStrategy struct{ duplicateLastStrategy }
+
+func (s slowStrategy) NextLevel(level, count int) []Pair {
+	if count > strategyCheckLeaves {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return s.duplicateLastStrategy.NextLevel(level, count)
+}
+
+func TestRegisterProcessType(t *testing.T) {
+	duplicateLast, err := RegisterProcessType("TEST-DUP", duplicateLastStrategy{})
+	if err != nil {
+		t.Fatalf("(err) got %q, wanted nil", err)
+	}
+	swapped, _ := RegisterProcessType("TEST-SWAP", swappedStrategy{})
+
+	for count := 2; count <= 20; count++ {
+		leaves := makeIndexedLeaves(count)
+
+		expected, _ := DeriveRoot(leaves, "SHA256SUM256", DupeAppend)
+		output, err := DeriveRoot(leaves, "SHA256SUM256", duplicateLast)
+		if err != nil {
+			t.Fatalf("count %d: (err) got %q, wanted nil", count, err)
+		}
+		if !bytes.Equal(output, expected) {
+			t.Errorf("count %d: (out) got %x, wanted %x", count, output, expected)
+		}
+
+		tree, _ := BuildTree(leaves, "SHA256SUM256", duplicateLast)
+		if !bytes.Equal(tree.Root(), expected) {
+			t.Errorf("count %d: (tree) got %x, wanted %x", count, tree.Root(), expected)
+		}
+
+		builder, _ := NewBuilder("SHA256SUM256", duplicateLast)
+		for _, leaf := range leaves {
+			builder.Append(leaf)
+		}
+		if built, _ := builder.Root(); !bytes.Equal(built, expected) {
+			t.Errorf("count %d: (builder) got %x, wanted %x", count, built, expected)
+		}
+
+		for _, processType := range []int{duplicateLast, swapped} {
+			root, _ := DeriveRoot(leaves, "SHA256SUM256", processType)
+			for i, leaf := range leaves {
+				proof, err := GenerateProof(leaves, i, "SHA256SUM256", processType)
+				if err != nil {
+					t.Fatalf("process %d, count %d, leaf %d: (proof err) got %q, wanted nil", processType, count, i, err)
+				}
+				if ok, err := VerifyProof(leaf, proof, root, "SHA256SUM256", processType); !ok || err != nil {
+					t.Errorf("process %d, count %d, leaf %d: (verify) got %v (%v), wanted true", processType, count, i, ok, err)
+				}
+			}
+		}
+	}
+
+	var duplicate *DuplicateProcessTypeErr
+	for _, name := range []string{"TEST-DUP", "pas-thru"} {
+		if _, err := RegisterProcessType(name, swappedStrategy{}); !errors.As(err, &duplicate) {
+			t.Errorf("%s: (err) got %v, wanted DuplicateProcessTypeErr", name, err)
+		}
+	}
+
+	// impossible levels: rejected at registration, or when planned
+	var invalid *InvalidStrategyErr
+	for name, strategy := range map[string]Strategy{"TEST-STUCK": stuckStrategy{}, "TEST-REUSE": reusingStrategy{}} {
+		if _, err := RegisterProcessType(name, strategy); !errors.As(err, &invalid) || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s: (err) got %v, wanted InvalidStrategyErr naming it", name, err)
+		}
+	}
+	dropping, err := RegisterProcessType("TEST-DROP", droppingStrategy{})
+	if err != nil {
+		t.Fatalf("(err) got %q, wanted nil", err)
+	}
+	for _, count := range []int{2 * strategyCheckLeaves, 2*strategyCheckLeaves + 1} {
+		_, err := DeriveRoot(makeIndexedLeaves(count), "SHA256SUM256", dropping)
+		if (err == nil) != (count == 2*strategyCheckLeaves) || (err != nil && (!errors.As(err, &invalid) || !strings.Contains(err.Error(), "TEST-DROP"))) {
+			t.Errorf("count %d: (err) got %v", count, err)
+		}
+	}
+
+	slow, _ := RegisterProcessType("TEST-SLOW", slowStrategy{})
+	ms, _ := New(WithProcessType(slow), WithTimeout(30*time.Millisecond))
+	var timedOut *ProcessTimedOutErr
+	if _, err := ms.Derive(makeIndexedLeaves(64)); !errors.As(err, &timedOut) {
+		t.Errorf("(err) got %v, wanted ProcessTimedOutErr", err)
+	}
+}
@@ -0,0 +1,122 @@
+package merkletree
+
+//
+// Retained tree structure.
+//
+// Functions:
+//
+//	- BuildTree:
+//		Builds the tree with the requested process type, keeping every level
+//		instead of only the root.
+//
+// Levels:
+//
+//	- Level 0 holds the leaves, level Depth() holds the root.
+//	- A level holds its nodes after pairing: promoted (PassThrough) or unpaired
+//	  (BinaryTree first level) nodes appear as is; pads (DupeAppend duplicates) are not stored.
+//
+
+import (
+	"context"
+	"slices"
+)
+
+// Merkle tree with all of its levels.
+type Tree struct {
+	HashTypeID  string `json:"hashtype"`
+	DigestSize  int    `json:"digestsize"`
+	ProcessType int    `json:"processtype"`
+	levels      [][][]byte
+	service     *MerkleService
+}
+
+/*
+Entry Point (tree)
+  - Tree built from hashes, with all levels retained.
+*/
+func BuildTree(hashes [][]byte, algorithmRequested string, processType int) (*Tree, error) {
+	ms, err := New(WithAlgorithm(algorithmRequested), WithProcessType(processType))
+	if err != nil {
+		return nil, err
+	}
+
+	return ms.BuildTree(hashes)
+}
+
+// Tree built from hashes with the service's configuration.
+func (ms *MerkleService) BuildTree(hashes [][]byte) (*Tree, error) {
+	return ms.BuildTreeContext(context.Background(), hashes)
+}
+
+// Tree built from hashes under ctx.
+func (ms *MerkleService) BuildTreeContext(ctx context.Context, hashes [][]byte) (*Tree, error) {
+	job, err := ms.newJob(hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Leaves are kept: level 0, recorded once they are prepared, copies them.
+	job.retainLevels = true
+
+	if err := ms.execute(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return &Tree{
+		HashTypeID:  ms.HashTypeID,
+		DigestSize:  ms.digestSize,
+		ProcessType: ms.ProcessType,
+		levels:      job.levels,
+		service:     ms,
+	}, nil
+}
+
+// Root of the tree (a copy).
+func (t *Tree) Root() []byte {
+	return t.service.finalRoot(slices.Clone(t.levels[len(t.levels)-1][0]))
+}
+
+// Number of levels above the leaves.
+func (t *Tree) Depth() int {
+	return len(t.levels) - 1
+}
+
+// Number of leaves.
+func (t *Tree) LeafCount() int {
+	return len(t.levels[0])
+}
+
+// Nodes of level n (0: leaves, Depth(): root). Copies: the tree is not changed through them.
+func (t *Tree) Level(n int) ([][]byte, error) {
+	if n < 0 || n >= len(t.levels) {
+		return nil, &NodeIndexErr{n, 0}
+	}
+
+	level := make([][]byte, len(t.levels[n]))
+	for i, node := range t.levels[n] {
+		level[i] = slices.Clone(node)
+	}
+
+	return level, nil
+}
+
+// Node at index of level (a copy).
+func (t *Tree) Node(level, index int) ([]byte, error) {
+	if level < 0 || level >= len(t.levels) || index < 0 || index >= len(t.levels[level]) {
+		return nil, &NodeIndexErr{level, index}
+	}
+
+	return slices.Clone(t.levels[level][index]), nil
+}
+
+// Keep a copy of the current level, if requested.
+func (ms *MerkleService) recordLevel() {
+	if ms.retainLevels {
+		// the digest buffer is overwritten by the next level: copy the nodes
+		level := make([][]byte, len(ms.Leaves))
+		for i, node := range ms.Leaves {
+			level[i] = slices.Clone(node)
+		}
+		ms.levels = append(ms.levels, level)
+	}
+}
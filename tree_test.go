@@ -0,0 +1,90 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestBuildTree(t *testing.T) {
+	for _, processType := range []int{PassThrough, DupeAppend, BinaryTree} {
+		for count := 1; count <= 17; count++ {
+			if processType == BinaryTree && count == 1 {
+				continue
+			}
+
+			root, _ := DeriveRoot(makeIndexedLeaves(count), "SHA256SUM256", processType)
+			tree, err := BuildTree(makeIndexedLeaves(count), "SHA256SUM256", processType)
+			if err != nil {
+				t.Fatalf("(err) got %q, wanted nil", err)
+			}
+
+			if !bytes.Equal(tree.Root(), root) {
+				t.Errorf("process %d, %d leaves: (root) got %q, wanted %q", processType, count, hex.EncodeToString(tree.Root()), hex.EncodeToString(root))
+			}
+			if tree.LeafCount() != count {
+				t.Errorf("process %d: (leaves) got %d, wanted %d", processType, tree.LeafCount(), count)
+			}
+			if top, _ := tree.Level(tree.Depth()); len(top) != 1 {
+				t.Errorf("process %d, %d leaves: (top level) got %d nodes, wanted 1", processType, count, len(top))
+			}
+		}
+	}
+
+	// 5 leaves: [0] [1] [2] [3] [4]
+	leaves := makeIndexedLeaves(5)
+	node01 := SHA256SUM256(slices.Concat(leaves[0], leaves[1]))
+	node34 := SHA256SUM256(slices.Concat(leaves[3], leaves[4]))
+	node44 := SHA256SUM256(slices.Concat(leaves[4], leaves[4]))
+
+	for _, test := range []struct {
+		processType  int
+		depth        int
+		level, index int
+		expected     []byte
+	}{
+		{PassThrough, 3, 1, 0, node01},
+		{PassThrough, 3, 1, 2, leaves[4]},
+		{DupeAppend, 3, 1, 2, node44},
+		{BinaryTree, 3, 1, 2, leaves[2]},
+		{BinaryTree, 3, 1, 3, node34},
+	} {
+		tree, _ := BuildTree(makeIndexedLeaves(5), "SHA256SUM256", test.processType)
+		if tree.Depth() != test.depth {
+			t.Errorf("process %d: (depth) got %d, wanted %d", test.processType, tree.Depth(), test.depth)
+		}
+		if node, err := tree.Node(test.level, test.index); err != nil || !bytes.Equal(node, test.expected) {
+			t.Errorf("process %d: (node %d,%d) got %q (%v), wanted %q", test.processType, test.level, test.index, hex.EncodeToString(node), err, hex.EncodeToString(test.expected))
+		}
+	}
+
+	tree, _ := BuildTree(makeIndexedLeaves(5), "SHA256SUM256", PassThrough)
+	var nodeErr *NodeIndexErr
+	if _, err := tree.Node(1, 3); !errors.As(err, &nodeErr) {
+		t.Errorf("(err) got %v, wanted *NodeIndexErr", err)
+	}
+	if _, err := tree.Level(4); !errors.As(err, &nodeErr) {
+		t.Errorf("(err) got %v, wanted *NodeIndexErr", err)
+	}
+
+	// nodes are returned as copies: writing to them does not change the tree
+	root := tree.Root()
+	node, _ := tree.Node(1, 0)
+	level, _ := tree.Level(1)
+	top, _ := tree.Level(tree.Depth())
+	clear(node)
+	clear(level[1])
+	clear(top[0])
+	clear(tree.Root())
+	if node, _ := tree.Node(1, 0); !bytes.Equal(node, node01) {
+		t.Errorf("(node) changed through a returned node")
+	}
+	if node, _ := tree.Node(1, 1); bytes.Equal(node, level[1]) {
+		t.Errorf("(node) changed through a returned level")
+	}
+	if !bytes.Equal(tree.Root(), root) {
+		t.Errorf("(root) changed through a returned level or root")
+	}
+}